@@ -9,9 +9,7 @@ var appTemplate = []embeddedTemplateFile{
 		[]byte(`{{template "FileHeader" . -}}
 AC_INIT([{{.name}}], [{{.version}}])
 AC_CONFIG_AUX_DIR([config])
-{{if gt (len (Dir "m4")) 0 -}}
 AC_CONFIG_MACRO_DIRS([m4])
-{{end -}}
 {{$sources := Dir "src" -}}
 {{if eq (len $sources) 0}}
 {{Error "'app' template requires at least one source file in src/"}}
@@ -24,35 +22,9 @@ test -z "$CXXFLAGS" && CXXFLAGS=""
 
 AC_PROG_CXX
 LT_INIT([disable-shared])
-
-dnl When compiling with GNU C++, display more warnings.
-AS_IF([test "$GXX" = yes],
-	[CXXFLAGS="$CXXFLAGS -ansi -pedantic -Wall \
--Woverloaded-virtual -Wsign-promo -W -Wshadow -Wpointer-arith -Wcast-qual \
--Wwrite-strings -Wconversion -Wsign-compare -Wredundant-decls -Winline"],
-dnl Display all levels of the Digital (Compaq) C++ warnings.
-[test "$CXX" = cxx &&
-	cxx -V < /dev/null 2>&1 | grep -Eiq 'digital|compaq'],
-	[DIGITALCXX="yes"
-	CXXFLAGS="$CXXFLAGS -w0 -msg_display_tag -std strict_ansi"],
-dnl Enable all warnings and remarks of the Intel C++ compiler.
-[test "$CXX" = icpc && icpc -V < /dev/null 2>&1 | grep -iq intel],
-	[CXXFLAGS="$CXXFLAGS -w2"])
-
-AC_ARG_ENABLE(debug, AS_HELP_STRING([--enable-debug],
-	[enable debug info and runtime checks (default=no)]))
-
-AM_CONDITIONAL(DEBUG, [test "$enable_debug" = yes])
-
-AS_IF([test "$enable_debug" != yes],
-	[CXXFLAGS="$CXXFLAGS -O3"],
-[CPPFLAGS="$CPPFLAGS -D{{VarNameUC .name}}_DEBUG"
-AS_IF([test "$GXX" = yes],
-	[CXXFLAGS="$CXXFLAGS -ggdb"],
-[test "$DIGITALCXX" = yes],
-	[CXXFLAGS="$CXXFLAGS -gall"],
-[test "$ac_cv_prog_cxx_g" = yes],
-	[CXXFLAGS="$CXXFLAGS -g"])])
+{{template "GrammarTools" .}}
+{{template "CXXFlags" . -}}
+{{template "ConfigureOptions" . -}}
 {{if or .external_libs .requires}}
 dnl Checks for libraries.{{end}}{{if .external_libs}}{{range .external_libs}}
 AC_CHECK_LIB([{{.name}}], [{{.function}}],,
@@ -65,34 +37,137 @@ PKG_CHECK_MODULES([{{VarNameUC .}}], [{{VarName .}}])
 CXXFLAGS="$CXXFLAGS ${{VarNameUC .}}_CFLAGS"
 LIBS="$LIBS ${{VarNameUC .}}_LIBS"
 {{end}}{{end -}}
-{{template "Snippet" .}}
+{{template "SnippetChecks" .}}
 AC_CONFIG_FILES([Makefile
-src/Makefile])
+src/Makefile{{range .target_dir}}
+src/{{.}}/Makefile{{end}}{{range .extra_subdirs}}
+{{.}}/Makefile{{end}}])
+{{template "SnippetOutput" . -}}
 AC_OUTPUT
 `)},
 	{"Makefile.am", 0644,
 		[]byte(`{{template "FileHeader" . -}}
-{{if gt (len (Dir "m4")) 0 -}}
+{{template "SnippetTop" . -}}
 ACLOCAL_AMFLAGS = -I m4
 
-{{end -}}
 AUTOMAKE_OPTIONS = foreign
 
-SUBDIRS = . src
+SUBDIRS = . src{{range .extra_subdirs}} {{.}}{{end}}
 
 EXTRA_DIST = autogen.sh
-`)},
+
+{{if .bench_dir -}}
+bench: all
+	cd bench && $(MAKE) $(AM_MAKEFLAGS) bench
+
+.PHONY: bench
+
+{{end -}}
+{{template "CoverageTarget" .}}
+clean-local: clean-coverage
+{{template "SnippetBottom" .}}`)},
 	{"src/Makefile.am", 0644,
 		[]byte(`{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+{{$allFiles := Dir .dirname -}}
+{{if .target_dir -}}
+SUBDIRS ={{range .target_dir}} {{.}}{{end}}
+{{if not (Exists "src/version.h")}}
+noinst_HEADERS = version.h
+{{end -}}
+{{$strayFiles := $allFiles -}}
+{{range .target_dir}}{{$strayFiles = Exclude $strayFiles (StringList (print . "/**"))}}{{end -}}
+{{with OptionalSources $strayFiles .options}}
+{{Error (print "optional source file 'src/" (index . 0) "' must be in the directory of a target")}}
+{{end -}}
+{{with $strayFiles}}
+EXTRA_DIST ={{template "Multiline" .}}
+{{end -}}
+{{else -}}
 bin_PROGRAMS = {{.name}}
 
-{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" -}}
+{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" "*?.l" "*?.ll" "*?.y" "*?.yy" -}}
+{{if .sources}}{{$sourceExt = .sources}}{{end -}}
+{{$sources := Select $allFiles $sourceExt -}}
+{{$optionalSources := OptionalSources $sources .options -}}
+{{$prefix := VarName .name -}}
+{{$prefix}}_SOURCES ={{template "Multiline" Exclude $sources .optional_source_patterns}}
+{{- if not (Exists "src/version.h")}} \
+	version.h{{end}}
+{{range .options}}{{$conditional := .Conditional -}}
+{{with Select $optionalSources .Sources}}
+if {{$conditional}}
+{{$prefix}}_SOURCES +={{template "Multiline" .}}
+endif
+{{end}}{{end -}}
+{{with .generated_outputs}}
+nodist_{{$prefix}}_SOURCES ={{template "Multiline" .}}
+{{end -}}
+{{template "GeneratedSources" .generated_sources -}}
+{{$extraFiles := Exclude (Exclude $allFiles $sourceExt) .generated_inputs -}}
+{{if or $extraFiles $optionalSources .generated_inputs}}
+EXTRA_DIST ={{template "Multiline" $extraFiles -}}
+{{template "Multiline" $optionalSources -}}
+{{template "Multiline" .generated_inputs}}
+{{end -}}
+{{end -}}
+{{template "SnippetBottom" .}}`)},
+	{"src/version.h", 0644,
+		[]byte(`{{template "VersionHeader" .}}`)},
+	{"src/{target_dir}/Makefile.am", 0644,
+		[]byte(`{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+{{$target := index .target_by_dir .target_dir -}}
+{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" "*?.l" "*?.ll" "*?.y" "*?.yy" -}}
 {{$allFiles := Dir .dirname -}}
-{{VarName .name -}}
-_SOURCES ={{template "Multiline" Select $allFiles $sourceExt}}
-{{$extraFiles := Exclude $allFiles $sourceExt -}}
-{{if $extraFiles}}
-EXTRA_DIST ={{template "Multiline" $extraFiles}}
+{{$patterns := $sourceExt -}}
+{{if $target.Sources}}{{$patterns = $target.Sources}}{{end -}}
+{{$sources := Select $allFiles $patterns -}}
+{{if eq (len $sources) 0}}
+{{Error (print "no source files found for '" $target.Name "' in " .dirname)}}
+{{end -}}
+{{$optionalSources := OptionalSources $sources .options -}}
+AM_CPPFLAGS = -I$(top_srcdir)/src
+
+{{$prefix := VarName $target.Name -}}
+{{if $target.IsProgram -}}
+{{if $target.Install}}bin{{else}}noinst{{end}}_PROGRAMS = {{$target.Name}}
+
+{{else -}}
+{{$prefix = print "lib" $prefix "_la" -}}
+{{if $target.Install}}lib{{else}}noinst{{end}}_LTLIBRARIES = lib{{$target.Name}}.la
+
+{{end -}}
+{{$prefix}}_SOURCES ={{template "Multiline" Exclude $sources .optional_source_patterns}}
+{{range .options}}{{$conditional := .Conditional -}}
+{{with Select $optionalSources .Sources}}
+if {{$conditional}}
+{{$prefix}}_SOURCES +={{template "Multiline" .}}
+endif
+{{end}}{{end -}}
+{{with $target.GeneratedOutputs}}
+nodist_{{$prefix}}_SOURCES ={{template "Multiline" .}}
+{{end -}}
+{{if $target.Link}}
+{{$prefix}}_{{if $target.IsProgram}}LDADD{{else}}LIBADD{{end}} ={{template "Multiline" $target.Link}}
+{{end -}}
+{{template "GeneratedSources" $target.GeneratedSources -}}
+{{$generatedInputs := $target.GeneratedInputs -}}
+{{$extraFiles := Exclude (Exclude $allFiles $patterns) $generatedInputs -}}
+{{if or $extraFiles $optionalSources $generatedInputs}}
+EXTRA_DIST ={{template "Multiline" $extraFiles -}}
+{{template "Multiline" $optionalSources -}}
+{{template "Multiline" $generatedInputs}}
 {{end -}}
-{{template "Snippet" .}}`)},
+{{template "SnippetBottom" .}}`)},
+	{"{man_dir}/Makefile.am", 0644,
+		[]byte(`{{template "ManMakefile" .}}`)},
+	{"{data_dir}/Makefile.am", 0644,
+		[]byte(`{{template "DataMakefile" .}}`)},
+	{"{doc_dir}/Makefile.am", 0644,
+		[]byte(`{{template "DocMakefile" .}}`)},
+	{"{examples_dir}/Makefile.am", 0644,
+		[]byte(`{{template "ExamplesMakefile" .}}`)},
+	{"{bench_dir}/Makefile.am", 0644,
+		[]byte(`{{template "BenchMakefile" .}}`)},
 }
@@ -0,0 +1,137 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// buildBackend is the build system that is used to build the
+// selected packages. A backend generates the build files of each
+// package as well as the targets of the workspace meta-Makefile,
+// and knows how to configure the packages in the build directory.
+type buildBackend interface {
+	// packageGeneratorFunc returns a function that generates
+	// the build files of the package in 'packageDir'.
+	packageGeneratorFunc(pd *packageDefinition, packageDir string,
+		pi *packageIndex) (func() (bool, error), error)
+
+	// generateAndBootstrapPackages generates the build files for
+	// the selected packages, prepares them for configuring, and
+	// updates the workspace files.
+	generateAndBootstrapPackages(ws *workspace, pi *packageIndex,
+		selection packageDefinitionList, conftab *Conftab) error
+
+	// makefileTargets returns the targets of the meta-Makefile.
+	makefileTargets(ws *workspace, selection packageDefinitionList,
+		pi *packageIndex) []target
+
+	// configurePackages configures the selected
+	// packages in the build directory.
+	configurePackages(ws *workspace, pi *packageIndex,
+		selection packageDefinitionList, conftab *Conftab) error
+
+	// compileCommands returns the compile commands of the
+	// selected packages, which must have been configured.
+	compileCommands(ws *workspace,
+		selection packageDefinitionList) ([]compileCommand, error)
+}
+
+const defaultBackendName = "autotools"
+
+var backends = map[string]buildBackend{
+	"autotools": autotoolsBackend{},
+	"cmake":     cmakeBackend{},
+	"meson":     mesonBackend{},
+}
+
+// backendNames returns the sorted list of supported backends.
+func backendNames() []string {
+	var names []string
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getBackend(name string) (buildBackend, error) {
+	if name == "" {
+		name = defaultBackendName
+	}
+	if backend := backends[name]; backend != nil {
+		return backend, nil
+	}
+	return nil, errors.New("unknown backend '" + name +
+		"'; must be one of: " + strings.Join(backendNames(), ", "))
+}
+
+// Generators of the meta build file. The meta-Makefile is always
+// generated; the ninja generator adds a ninja file with the same
+// targets.
+const (
+	makeGenerator  = "make"
+	ninjaGenerator = "ninja"
+	generatorNames = makeGenerator + ", " + ninjaGenerator
+)
+
+// checkGenerator makes sure that the meta build
+// file generator is one of the supported ones.
+func checkGenerator(generator string) error {
+	switch generator {
+	case "", makeGenerator, ninjaGenerator:
+		return nil
+	}
+	return errors.New("unknown generator '" + generator +
+		"'; must be one of: " + generatorNames)
+}
+
+// autotoolsBackend generates GNU Autotools projects.
+type autotoolsBackend struct{}
+
+func (autotoolsBackend) packageGeneratorFunc(pd *packageDefinition,
+	packageDir string, pi *packageIndex) (func() (bool, error), error) {
+	return pd.getPackageGeneratorFunc(packageDir, pi)
+}
+
+func (autotoolsBackend) makefileTargets(ws *workspace,
+	selection packageDefinitionList, pi *packageIndex) []target {
+	return createMakefileTargets(ws, selection, pi)
+}
+
+func (autotoolsBackend) compileCommands(ws *workspace,
+	selection packageDefinitionList) ([]compileCommand, error) {
+	var commands []compileCommand
+
+	for _, pd := range selection {
+		pkgBuildDir := path.Join(ws.buildDir(), pd.PackageName)
+
+		_, err := os.Stat(path.Join(pkgBuildDir, "Makefile"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, errors.New(pd.PackageName +
+					": package has not been configured")
+			}
+			return nil, err
+		}
+
+		if !flags.quiet {
+			fmt.Println("[compdb] " + pd.PackageName)
+		}
+
+		pkgCommands, err := makeDryRunCompileCommands(pkgBuildDir)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, pkgCommands...)
+	}
+
+	return commands, nil
+}
@@ -0,0 +1,39 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+)
+
+func TestGetBackend(t *testing.T) {
+	backend, err := getBackend("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := backend.(autotoolsBackend); !ok {
+		t.Error("autotools must be the default backend")
+	}
+
+	backend, err = getBackend("cmake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := backend.(cmakeBackend); !ok {
+		t.Error("unexpected backend for 'cmake'")
+	}
+
+	backend, err = getBackend("meson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := backend.(mesonBackend); !ok {
+		t.Error("unexpected backend for 'meson'")
+	}
+
+	if _, err = getBackend("scons"); err == nil {
+		t.Error("unknown backend was accepted")
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// buildTarget describes a single entry of either the 'programs'
+// or the 'libraries' list in a package definition file.
+type buildTarget struct {
+	Name      string   // Program or library name
+	Dir       string   // Subdirectory of src/ with the sources
+	Sources   []string // Source file patterns; all sources if empty
+	Link      []string // Resolved link dependencies
+	LinkNames []string // Link dependencies as given in the definition
+	Install   bool     // Whether the library must be installed
+	IsProgram bool     // True for programs, false for libraries
+
+	// Rules that generate sources in the directory of the target
+	GeneratedSources []*generatedSource
+}
+
+// GeneratedInputs returns the inputs of all rules
+// that generate sources for the target.
+func (target *buildTarget) GeneratedInputs() []string {
+	var inputs []string
+	for _, rule := range target.GeneratedSources {
+		inputs = append(inputs, rule.Inputs...)
+	}
+	return inputs
+}
+
+// GeneratedOutputs returns the outputs of all rules
+// that generate sources for the target.
+func (target *buildTarget) GeneratedOutputs() []string {
+	var outputs []string
+	for _, rule := range target.GeneratedSources {
+		outputs = append(outputs, rule.Outputs...)
+	}
+	return outputs
+}
+
+// getStringListField returns the value of the specified field
+// as a list of strings. A single string is accepted as a list
+// consisting of one element.
+func getStringListField(pathname string, value interface{},
+	fieldName string) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if stringValue, ok := value.(string); ok {
+		return []string{stringValue}, nil
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New(pathname +
+			": '" + fieldName + "' must be a list")
+	}
+
+	var result []string
+
+	for _, elem := range list {
+		stringElem, ok := elem.(string)
+		if !ok {
+			return nil, errors.New(pathname +
+				": '" + fieldName + "' must be " +
+				"a list of strings")
+		}
+		result = append(result, stringElem)
+	}
+
+	return result, nil
+}
+
+func parseBuildTargetList(pathname string, params templateParams,
+	fieldName string, isProgram bool) ([]*buildTarget, error) {
+	value := params[fieldName]
+	if value == nil {
+		return nil, nil
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New(pathname +
+			": '" + fieldName + "' must be a list")
+	}
+
+	var targets []*buildTarget
+
+	for _, elem := range list {
+		entry, ok := elem.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.New(pathname + ": each entry in '" +
+				fieldName + "' must be a map")
+		}
+
+		entryParams := templateParams{}
+		for key, val := range entry {
+			if keyStr, ok := key.(string); ok {
+				entryParams[keyStr] = val
+			}
+		}
+
+		name, err := getRequiredStringField(pathname,
+			entryParams, "name")
+		if err != nil {
+			return nil, err
+		}
+
+		target := &buildTarget{Name: name, Dir: name,
+			IsProgram: isProgram}
+
+		if dir, ok := entryParams["dir"].(string); ok {
+			target.Dir = dir
+		}
+
+		// The directory is a single component of
+		// a pathname relative to src/.
+		if target.Dir == "" || target.Dir == "." ||
+			target.Dir == ".." || strings.Contains(target.Dir, "/") {
+			return nil, errors.New(pathname + ": '" + target.Name +
+				"': 'dir' must be the name of a subdirectory " +
+				"of src/")
+		}
+
+		target.Sources, err = getStringListField(pathname,
+			entryParams["sources"], fieldName+": sources")
+		if err != nil {
+			return nil, err
+		}
+
+		target.Link, err = getStringListField(pathname,
+			entryParams["link"], fieldName+": link")
+		if err != nil {
+			return nil, err
+		}
+
+		if install, ok := entryParams["install"].(bool); ok {
+			target.Install = install
+		} else {
+			target.Install = isProgram
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// parseBuildTargets processes the 'programs' and 'libraries' lists
+// of the package definition and replaces them in 'params' with their
+// parsed equivalents. It also defines the 'target_dir' parameter,
+// which is a list of source subdirectories that is used to multiply
+// the per-target makefile template, the 'target_by_dir' map
+// from those subdirectories to the respective targets, and the
+// 'library_by_name' map from library names to libraries.
+// Only application packages can define build targets.
+func parseBuildTargets(pathname, packageType string,
+	params templateParams) error {
+	programs, err := parseBuildTargetList(pathname, params,
+		"programs", true)
+	if err != nil {
+		return err
+	}
+
+	libraries, err := parseBuildTargetList(pathname, params,
+		"libraries", false)
+	if err != nil {
+		return err
+	}
+
+	if (programs != nil || libraries != nil) &&
+		packageType != "app" && packageType != "application" {
+		return errors.New(pathname + ": 'programs' and 'libraries' " +
+			"are only supported by application packages")
+	}
+
+	targetDirs := []string{}
+	targetByDir := map[string]*buildTarget{}
+	libraryByName := map[string]*buildTarget{}
+
+	for _, target := range append(libraries, programs...) {
+		if dup := targetByDir[target.Dir]; dup != nil {
+			return errors.New(pathname + ": '" + target.Name +
+				"' and '" + dup.Name +
+				"' share the same directory src/" + target.Dir)
+		}
+		targetDirs = append(targetDirs, target.Dir)
+		targetByDir[target.Dir] = target
+		if !target.IsProgram {
+			libraryByName[target.Name] = target
+		}
+	}
+
+	// Replace references to the libraries defined in
+	// the same package with relative pathnames of the
+	// respective libtool archives. Other link dependencies
+	// are passed to the linker verbatim.
+	for _, target := range targetByDir {
+		target.LinkNames = append([]string{}, target.Link...)
+		for i, dep := range target.Link {
+			if lib := libraryByName[dep]; lib != nil {
+				target.Link[i] = "../" + lib.Dir +
+					"/lib" + lib.Name + ".la"
+			}
+		}
+	}
+
+	// Each source generation rule belongs to the target
+	// that contains the outputs of the rule.
+	if rules, ok := params["generated_sources"].([]*generatedSource); ok &&
+		len(targetDirs) > 0 {
+		for _, rule := range rules {
+			assigned := false
+			for _, dir := range targetDirs {
+				if r, ok := rule.relativeTo(dir); ok {
+					target := targetByDir[dir]
+					target.GeneratedSources = append(
+						target.GeneratedSources, r)
+					assigned = true
+					break
+				}
+			}
+			if !assigned {
+				return errors.New(pathname + ": outputs of " +
+					"generated source rule '" + rule.Command +
+					"' must be in the directory of a target")
+			}
+		}
+	}
+
+	if programs != nil {
+		params["programs"] = programs
+	}
+	if libraries != nil {
+		params["libraries"] = libraries
+	}
+	params["target_dir"] = targetDirs
+	params["target_by_dir"] = targetByDir
+	params["library_by_name"] = libraryByName
+
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func parseBuildTargetsForTesting(t *testing.T, definition string) (
+	templateParams, error) {
+	var params templateParams
+
+	if err := yaml.Unmarshal([]byte(definition), &params); err != nil {
+		t.Fatal(err)
+	}
+
+	return params, parseBuildTargets("test.yaml", "app", params)
+}
+
+func TestBuildTargets(t *testing.T) {
+	params, err := parseBuildTargetsForTesting(t, `
+programs:
+  - name: food
+    dir: daemon
+    link: [util, -lm]
+  - name: foo
+libraries:
+  - name: util
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(params["target_dir"],
+		[]string{"util", "daemon", "foo"}) {
+		t.Error("Unexpected target directories:", params["target_dir"])
+	}
+
+	daemon := params["target_by_dir"].(map[string]*buildTarget)["daemon"]
+
+	if daemon == nil || !daemon.IsProgram || !daemon.Install {
+		t.Fatal("Program definition was not parsed correctly")
+	}
+
+	if !reflect.DeepEqual(daemon.Link,
+		[]string{"../util/libutil.la", "-lm"}) {
+		t.Error("Link dependencies were not resolved:", daemon.Link)
+	}
+}
+
+func TestNoBuildTargets(t *testing.T) {
+	params, err := parseBuildTargetsForTesting(t, "name: foo\n")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dirs, ok := params["target_dir"].([]string); !ok || len(dirs) != 0 {
+		t.Error("'target_dir' must be an empty list")
+	}
+}
+
+func TestBuildTargetDirConflict(t *testing.T) {
+	_, err := parseBuildTargetsForTesting(t, `
+programs:
+  - name: foo
+libraries:
+  - name: bar
+    dir: foo
+`)
+	if err == nil || !strings.Contains(err.Error(),
+		"share the same directory") {
+		t.Error("Directory conflict was not detected")
+	}
+}
+
+func TestBuildTargetDirValidation(t *testing.T) {
+	for _, dir := range []string{"../x", "a/b", "..", "''"} {
+		_, err := parseBuildTargetsForTesting(t, `
+programs:
+  - name: foo
+    dir: `+dir+`
+`)
+		if err == nil || !strings.Contains(err.Error(),
+			"must be the name of a subdirectory") {
+			t.Error("Invalid directory was accepted: " + dir)
+		}
+	}
+}
+
+func TestBuildTargetsInNonAppPackage(t *testing.T) {
+	var params templateParams
+
+	if err := yaml.Unmarshal([]byte(`
+libraries:
+  - name: util
+`), &params); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := parseBuildTargets("test.yaml", "lib", params); err == nil {
+		t.Error("Build targets were accepted in a library package")
+	}
+}
+
+func parseTargetsWithGeneratedSources(t *testing.T,
+	outputs string) (templateParams, error) {
+	var params templateParams
+
+	if err := yaml.Unmarshal([]byte(`
+programs:
+  - name: foo
+generated_sources:
+  - command: gen
+    inputs: [foo/a.in, common.in]
+    outputs: `+outputs+`
+`), &params); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := parseGeneratedSources("test.yaml", params); err != nil {
+		t.Fatal(err)
+	}
+
+	return params, parseBuildTargets("test.yaml", "app", params)
+}
+
+func TestGeneratedSourcesOfTargets(t *testing.T) {
+	params, err := parseTargetsWithGeneratedSources(t, "[foo/a.cc]")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foo := params["target_by_dir"].(map[string]*buildTarget)["foo"]
+
+	if !reflect.DeepEqual(foo.GeneratedOutputs(), []string{"a.cc"}) ||
+		!reflect.DeepEqual(foo.GeneratedInputs(),
+			[]string{"a.in", "../common.in"}) {
+		t.Error("Generated sources were not assigned to the target")
+	}
+
+	_, err = parseTargetsWithGeneratedSources(t, "[bar/b.cc]")
+	if err == nil || !strings.Contains(err.Error(),
+		"must be in the directory of a target") {
+		t.Error("Rule outside of target directories was accepted")
+	}
+}
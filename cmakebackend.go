@@ -0,0 +1,167 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+)
+
+// cmakeBackend generates CMake projects. The selected packages
+// are combined into a single superbuild project, which is
+// configured in the build directory of the workspace.
+type cmakeBackend struct{}
+
+func (cmakeBackend) packageGeneratorFunc(pd *packageDefinition,
+	packageDir string, pi *packageIndex) (func() (bool, error), error) {
+	var t []embeddedTemplateFile
+
+	switch pd.packageType {
+	case "app", "application":
+		t = cmakeAppTemplate
+	case "lib", "library":
+		t = cmakeLibTemplate
+	case "module", "plugin":
+		t = cmakeModuleTemplate
+	default:
+		return nil, errors.New(pd.PackageName +
+			": unknown package type '" + pd.packageType + "'")
+	}
+
+	return func() (bool, error) {
+		return generateBuildFilesFromEmbeddedTemplate(t,
+			cmakeDefinitions, packageDir, pd, pi)
+	}, nil
+}
+
+func (b cmakeBackend) generateAndBootstrapPackages(ws *workspace,
+	pi *packageIndex, selection packageDefinitionList,
+	conftab *Conftab) error {
+	if _, err := generatePackageFiles(b, ws, pi, selection); err != nil {
+		return err
+	}
+
+	addPackageOptionsToConftab(conftab, selection)
+
+	// Generate the superbuild project, which adds the
+	// selected packages as subdirectories.
+	outputFiles, err := parseAndExecuteTemplate(
+		cmakeSuperbuildTemplate.pathname,
+		cmakeSuperbuildTemplate.contents, nil, nil,
+		[]outputFileParams{{cmakeSuperbuildTemplate.pathname,
+			templateParams{"selection": selection}}})
+	if err != nil {
+		return err
+	}
+	_, err = writeGeneratedFiles(ws.generatedPkgRootDir(), outputFiles,
+		cmakeSuperbuildTemplate.mode)
+	if err != nil {
+		return err
+	}
+
+	return generateWorkspaceFiles(ws, b, pi, selection, conftab)
+}
+
+func (cmakeBackend) makefileTargets(ws *workspace,
+	selection packageDefinitionList, pi *packageIndex) []target {
+	relBuildDir := ws.buildDirRelativeToWorkspace()
+	cacheFile := path.Join(relBuildDir, "CMakeCache.txt")
+	configureCmd := "\t@" + selfPathnameRelativeToWorkspace(ws) +
+		" configure\n"
+
+	targets := []target{
+		{"help", true, nil, `	@echo "Usage:"
+	@echo "    make [target...]"
+	@echo
+	@echo "Global targets:"
+	@echo "    help"
+	@echo "        Display this help message. Unless overridden by the"
+	@echo "        '--` + maketargetOption + `' option, this is the default target."
+	@echo
+	@echo "    configure"
+	@echo "        Run CMake to (re)generate the build system for"
+	@echo "        the selected packages."
+	@echo
+	@echo "    build"
+	@echo "        Build (compile and link) the selected packages."
+	@echo "        CMake is run automatically if necessary."
+	@echo
+	@echo "    check"
+	@echo "        Build and run unit tests for the selected packages."
+	@echo
+	@echo "    install"
+	@echo "        Install package binaries and library headers into"
+	@echo "        '` + ws.installDir() + `'."
+	@echo
+`},
+		{cacheFile, false, []string{path.Join(
+			ws.pkgRootDirRelativeToWorkspace(), "CMakeLists.txt")},
+			configureCmd},
+		{"configure", true, nil, configureCmd},
+		{"build", true, []string{cacheFile},
+			"\t@cmake --build '" + relBuildDir + "'\n"},
+		{"check", true, []string{"build"},
+			"\t@cd '" + relBuildDir +
+				"' && ctest --output-on-failure\n"},
+	}
+
+	for _, pd := range selection {
+		targets = append(targets, target{"check_" + pd.PackageName,
+			true, []string{"build"}, "\t@cd '" +
+				path.Join(relBuildDir, pd.PackageName) +
+				"' && ctest --output-on-failure\n"})
+	}
+
+	return append(targets, target{"install", true, []string{"build"},
+		"\t@cmake --build '" + relBuildDir + "' --target install\n"})
+}
+
+func (cmakeBackend) configurePackages(ws *workspace, pi *packageIndex,
+	selection packageDefinitionList, conftab *Conftab) error {
+	if !flags.quiet {
+		fmt.Println("[configure] " + appName + " workspace")
+	}
+
+	buildDir := ws.buildDir()
+
+	if err := os.MkdirAll(buildDir, os.FileMode(0775)); err != nil {
+		return err
+	}
+
+	cmakeArgs := []string{"-S", ws.generatedPkgRootDir(), "-B", buildDir,
+		"-DCMAKE_INSTALL_PREFIX=" + ws.installDir(),
+		"-DCMAKE_EXPORT_COMPILE_COMMANDS=ON"}
+
+	// Options of the packages are set from the conftab.
+	for _, pd := range selection {
+		for _, opt := range packageOptions(pd) {
+			value := "OFF"
+			if opt.isOn(conftab, pd.PackageName) {
+				value = "ON"
+			}
+			cmakeArgs = append(cmakeArgs,
+				"-D"+opt.CMakeVar(pd.PackageName)+"="+value)
+		}
+	}
+
+	cmakeCmd := exec.Command("cmake", cmakeArgs...)
+	cmakeCmd.Stdout = os.Stdout
+	cmakeCmd.Stderr = os.Stderr
+	if err := cmakeCmd.Run(); err != nil {
+		return errors.New("cmake: " + err.Error())
+	}
+
+	return nil
+}
+
+func (cmakeBackend) compileCommands(ws *workspace,
+	selection packageDefinitionList) ([]compileCommand, error) {
+	// CMake generates a single compilation database
+	// for all packages of the superbuild project.
+	return readCompileCommands(path.Join(ws.buildDir(), compdbFilename))
+}
@@ -0,0 +1,260 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+// cmakeDefinitions extends the common definitions
+// with the fragments of the CMake templates.
+var cmakeDefinitions = withCommonDefinitions(map[string]string{
+	"CMakeProject": `{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+cmake_minimum_required(VERSION 3.12)
+
+{{$version := SplitVersion .version -}}
+project({{.name}} VERSION {{index $version 0}}.{{index $version 1}}.{{index $version 2}}
+	LANGUAGES C CXX)
+
+include(GNUInstallDirs)
+{{if .cxx_std}}
+set(CMAKE_CXX_STANDARD {{.cxx_std}})
+set(CMAKE_CXX_STANDARD_REQUIRED ON)
+set(CMAKE_CXX_EXTENSIONS OFF)
+{{end}}{{range .options}}
+option({{.CMakeVar $.name}} "{{.Help}}" {{if .Default}}ON{{else}}OFF{{end}})
+{{end}}{{range .requires}}
+if(NOT TARGET {{.}}::{{.}})
+	find_package({{.}} REQUIRED)
+endif()
+{{end}}`,
+	"CMakeSources": `{{range .}}
+	src/{{.}}{{end}}`,
+	"CMakeGeneratedSources": `{{with .generated_sources}}
+file(MAKE_DIRECTORY ${CMAKE_CURRENT_BINARY_DIR}/src)
+{{range .}}
+add_custom_command(OUTPUT{{range .Outputs}}
+		${CMAKE_CURRENT_BINARY_DIR}/src/{{.}}{{end}}
+	COMMAND {{.Command}}
+{{- with .Inputs}}
+	DEPENDS{{range .}}
+		${CMAKE_CURRENT_SOURCE_DIR}/src/{{.}}{{end}}{{end}}
+	WORKING_DIRECTORY ${CMAKE_CURRENT_BINARY_DIR}/src)
+{{end}}{{end}}`,
+	"CMakeTargetSettings": `{{$optionalSources := OptionalSources (Dir "src") .options -}}
+{{range .options}}{{$conditional := .CMakeVar $.name -}}
+{{with Select $optionalSources .Sources}}
+if({{$conditional}})
+	target_sources({{$.name}} PRIVATE{{template "CMakeSources" .}})
+endif()
+{{end}}{{if .Define}}
+if({{$conditional}})
+	target_compile_definitions({{$.name}} PRIVATE {{.Define}}=1)
+endif()
+{{end}}{{end -}}
+{{with .generated_outputs}}
+target_sources({{$.name}} PRIVATE{{range .}}
+	${CMAKE_CURRENT_BINARY_DIR}/src/{{.}}{{end}})
+{{end -}}
+{{if .warning_flags}}
+if(CMAKE_CXX_COMPILER_ID MATCHES "GNU|Clang")
+	target_compile_options({{.name}} PRIVATE {{.warning_flags}})
+endif()
+{{end -}}
+{{with .external_libs}}
+target_link_libraries({{$.name}} PRIVATE{{range .}} {{.name}}
+{{- with .other_libs}} {{.}}{{end}}{{end}})
+{{end}}`,
+})
+
+var cmakeSuperbuildTemplate = embeddedTemplateFile{"CMakeLists.txt", 0644,
+	[]byte(`# Superbuild project for the packages selected in the
+# workspace. Packages are added in dependency order.
+
+cmake_minimum_required(VERSION 3.12)
+
+project(` + appName + `_workspace LANGUAGES NONE)
+
+enable_testing()
+{{range .selection}}
+add_subdirectory({{.PackageName}})
+{{- end}}
+`)}
+
+var cmakeAppTemplate = []embeddedTemplateFile{
+	{"CMakeLists.txt", 0644,
+		[]byte(`{{template "CMakeProject" . -}}
+{{template "CMakeGeneratedSources" . -}}
+{{if .target_dir}}
+{{range .target_dir}}add_subdirectory(src/{{.}})
+{{end -}}
+{{else -}}
+{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" -}}
+{{if .sources}}{{$sourceExt = .sources}}{{end -}}
+{{$sources := Select (Dir "src") $sourceExt -}}
+{{if eq (len $sources) 0}}
+{{Error "'app' template requires at least one source file in src/"}}
+{{end}}
+add_executable({{.name}}{{template "CMakeSources" Exclude $sources .optional_source_patterns}})
+
+target_include_directories({{.name}} PRIVATE
+	${CMAKE_CURRENT_SOURCE_DIR}/src ${CMAKE_CURRENT_BINARY_DIR}/src)
+{{with .requires}}
+target_link_libraries({{$.name}} PRIVATE{{range .}} {{.}}::{{.}}{{end}})
+{{end -}}
+{{template "CMakeTargetSettings" .}}
+install(TARGETS {{.name}} RUNTIME DESTINATION ${CMAKE_INSTALL_BINDIR})
+{{end -}}
+{{template "SnippetBottom" .}}`)},
+	{"src/version.h", 0644,
+		[]byte(`{{template "VersionHeader" .}}`)},
+	{"src/{target_dir}/CMakeLists.txt", 0644,
+		[]byte(`{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+{{$target := index .target_by_dir .target_dir -}}
+{{$targetName := print .name "_" (VarName $target.Name) -}}
+{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" -}}
+{{$patterns := $sourceExt -}}
+{{if $target.Sources}}{{$patterns = $target.Sources}}{{end -}}
+{{$sources := Select (Dir .dirname) $patterns -}}
+{{if eq (len $sources) 0}}
+{{Error (print "no source files found for '" $target.Name "' in " .dirname)}}
+{{end -}}
+{{if $target.IsProgram -}}
+add_executable({{$targetName}}
+{{- else -}}
+add_library({{$targetName}}{{if not $target.Install}} STATIC{{end}}
+{{- end}}{{range $sources}}
+	{{.}}{{end}})
+
+set_target_properties({{$targetName}} PROPERTIES OUTPUT_NAME {{$target.Name}})
+
+target_include_directories({{$targetName}} PRIVATE
+	${PROJECT_SOURCE_DIR}/src ${PROJECT_BINARY_DIR}/src)
+{{if or $target.LinkNames .requires}}
+target_link_libraries({{$targetName}} PRIVATE
+{{- range $target.LinkNames}} {{if index $.library_by_name .}}{{$.name}}_{{VarName .}}{{else}}{{.}}{{end}}{{end}}
+{{- range .requires}} {{.}}::{{.}}{{end}})
+{{end -}}
+{{if $target.Install}}
+install(TARGETS {{$targetName}}
+	RUNTIME DESTINATION ${CMAKE_INSTALL_BINDIR}
+	LIBRARY DESTINATION ${CMAKE_INSTALL_LIBDIR}
+	ARCHIVE DESTINATION ${CMAKE_INSTALL_LIBDIR})
+{{end -}}
+{{template "SnippetBottom" .}}`)},
+}
+
+var cmakeLibTemplate = []embeddedTemplateFile{
+	{"CMakeLists.txt", 0644,
+		[]byte(`{{template "CMakeProject" . -}}
+{{template "CMakeGeneratedSources" . -}}
+{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" -}}
+{{if .sources}}{{$sourceExt = .sources}}{{end -}}
+{{$sources := Select (Dir "src") $sourceExt -}}
+{{if eq (len $sources) 0}}
+{{Error "'lib' template requires at least one source file in src/"}}
+{{end}}
+add_library({{.name}}{{template "CMakeSources" Exclude $sources .optional_source_patterns}})
+add_library({{.name}}::{{.name}} ALIAS {{.name}})
+
+target_include_directories({{.name}}
+	PUBLIC
+		$<BUILD_INTERFACE:${CMAKE_CURRENT_SOURCE_DIR}/include>
+		$<INSTALL_INTERFACE:${CMAKE_INSTALL_INCLUDEDIR}>
+	PRIVATE
+		${CMAKE_CURRENT_SOURCE_DIR}/src ${CMAKE_CURRENT_BINARY_DIR}/src)
+
+target_compile_definitions({{.name}} PRIVATE {{VarNameUC .name}}_BUILDING
+	$<$<BOOL:${BUILD_SHARED_LIBS}>:DLL_EXPORT>)
+{{if eq .visibility "hidden"}}
+set_target_properties({{.name}} PROPERTIES
+	CXX_VISIBILITY_PRESET hidden
+	VISIBILITY_INLINES_HIDDEN ON)
+{{end -}}
+{{with .public_requires}}
+target_link_libraries({{$.name}} PUBLIC{{range .}} {{.}}::{{.}}{{end}})
+{{end -}}
+{{with .private_requires}}
+target_link_libraries({{$.name}} PRIVATE{{range .}} {{.}}::{{.}}{{end}})
+{{end -}}
+{{template "CMakeTargetSettings" . -}}
+{{$testSources := Select (Dir "tests") (StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp") -}}
+{{with $testSources}}
+enable_testing()
+{{range .}}{{$test := print $.name "_" (VarName (TrimExt .))}}
+add_executable({{$test}} tests/{{.}})
+set_target_properties({{$test}} PROPERTIES OUTPUT_NAME {{TrimExt .}}
+	RUNTIME_OUTPUT_DIRECTORY ${CMAKE_CURRENT_BINARY_DIR}/tests)
+target_link_libraries({{$test}} PRIVATE {{$.name}})
+add_test(NAME {{$test}} COMMAND {{$test}})
+{{end}}{{end}}
+install(TARGETS {{.name}} EXPORT {{.name}}Targets
+	RUNTIME DESTINATION ${CMAKE_INSTALL_BINDIR}
+	LIBRARY DESTINATION ${CMAKE_INSTALL_LIBDIR}
+	ARCHIVE DESTINATION ${CMAKE_INSTALL_LIBDIR})
+
+{{$headerExt := StringList "*?.H" "*?.h" "*?.hh" "*?.hxx" "*?.hpp" -}}
+{{if .headers}}{{$headerExt = .headers}}{{end -}}
+{{$includeDir := print "include/" .name -}}
+install(FILES{{range Select (Dir $includeDir) $headerExt}}
+	{{$includeDir}}/{{.}}{{end}}
+{{- if not (Exists (print $includeDir "/export.h"))}}
+	{{$includeDir}}/export.h{{end}}
+{{- if not (Exists (print $includeDir "/version.h"))}}
+	{{$includeDir}}/version.h{{end}}
+	DESTINATION ${CMAKE_INSTALL_INCLUDEDIR}/{{.name}})
+
+install(EXPORT {{.name}}Targets NAMESPACE {{.name}}::
+	DESTINATION ${CMAKE_INSTALL_LIBDIR}/cmake/{{.name}})
+
+include(CMakePackageConfigHelpers)
+
+write_basic_package_version_file(
+	${CMAKE_CURRENT_BINARY_DIR}/{{.name}}ConfigVersion.cmake
+	COMPATIBILITY SameMajorVersion)
+
+install(FILES cmake/{{.name}}Config.cmake
+	${CMAKE_CURRENT_BINARY_DIR}/{{.name}}ConfigVersion.cmake
+	DESTINATION ${CMAKE_INSTALL_LIBDIR}/cmake/{{.name}})
+{{template "SnippetBottom" .}}`)},
+	{"cmake/{name}Config.cmake", 0644,
+		[]byte(`# CMake package configuration file for {{.name}}.
+{{with .requires}}
+include(CMakeFindDependencyMacro)
+{{range .}}
+find_dependency({{.}})
+{{- end}}
+{{end}}
+include("${CMAKE_CURRENT_LIST_DIR}/{{.name}}Targets.cmake")
+`)},
+	{"include/{name}/version.h", 0644,
+		[]byte(`{{template "VersionHeader" .}}`)},
+	{"include/{name}/export.h", 0644,
+		[]byte(`{{template "ExportHeader" .}}`)},
+}
+
+var cmakeModuleTemplate = []embeddedTemplateFile{
+	{"CMakeLists.txt", 0644,
+		[]byte(`{{template "CMakeProject" . -}}
+{{template "CMakeGeneratedSources" . -}}
+{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" -}}
+{{if .sources}}{{$sourceExt = .sources}}{{end -}}
+{{$sources := Select (Dir "src") $sourceExt -}}
+{{if eq (len $sources) 0}}
+{{Error "'module' template requires at least one source file in src/"}}
+{{end}}
+add_library({{.name}} MODULE{{template "CMakeSources" Exclude $sources .optional_source_patterns}})
+
+# Loadable modules do not have the 'lib' prefix.
+set_target_properties({{.name}} PROPERTIES PREFIX "")
+
+target_include_directories({{.name}} PRIVATE
+	${CMAKE_CURRENT_SOURCE_DIR}/src ${CMAKE_CURRENT_BINARY_DIR}/src)
+{{with .requires}}
+target_link_libraries({{$.name}} PRIVATE{{range .}} {{.}}::{{.}}{{end}})
+{{end -}}
+{{template "CMakeTargetSettings" .}}
+install(TARGETS {{.name}}
+	LIBRARY DESTINATION ${CMAKE_INSTALL_LIBDIR}/{{.name}})
+{{template "SnippetBottom" .}}`)},
+}
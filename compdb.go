@@ -0,0 +1,363 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const compdbFilename = "compile_commands.json"
+
+// compileCommand is an entry of a JSON compilation database.
+// The command line is given either as a list of arguments
+// or as a single shell command.
+type compileCommand struct {
+	Directory string   `json:"directory"`
+	Arguments []string `json:"arguments,omitempty"`
+	Command   string   `json:"command,omitempty"`
+	File      string   `json:"file"`
+}
+
+// splitShellWords splits a shell command line into words. Quotes
+// and backquotes are kept in the words, but the separators within
+// them are ignored. The command separators (';', '&&', '||', and
+// '|') that are not quoted are returned as separate words.
+func splitShellWords(line string) []string {
+	var words []string
+	var word []byte
+	inWord := false
+	var quote byte
+
+	endWord := func() {
+		if inWord {
+			words = append(words, string(word))
+			word = word[:0]
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		if quote != 0 {
+			if c == '\\' && quote != '\'' && i+1 < len(line) {
+				word = append(word, c)
+				i++
+				c = line[i]
+			} else if c == quote {
+				quote = 0
+			}
+			word = append(word, c)
+			continue
+		}
+
+		switch c {
+		case ' ', '\t':
+			endWord()
+		case '\'', '"', '`':
+			quote = c
+			word = append(word, c)
+			inWord = true
+		case '\\':
+			if i+1 < len(line) {
+				i++
+				word = append(word, line[i])
+				inWord = true
+			}
+		case ';':
+			endWord()
+			words = append(words, ";")
+		case '&', '|':
+			endWord()
+			if i+1 < len(line) && line[i+1] == c {
+				i++
+				words = append(words, string([]byte{c, c}))
+			} else {
+				words = append(words, string(c))
+			}
+		default:
+			word = append(word, c)
+			inWord = true
+		}
+	}
+	endWord()
+
+	return words
+}
+
+// unquoteShellWord removes the quotes from a word
+// returned by splitShellWords.
+func unquoteShellWord(word string) string {
+	var result []byte
+	var quote byte
+
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		switch {
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case c == quote:
+			quote = 0
+		case c == '\\' && quote == '"' && i+1 < len(word):
+			i++
+			result = append(result, word[i])
+		default:
+			result = append(result, c)
+		}
+	}
+
+	return string(result)
+}
+
+var compilerRegexp = regexp.MustCompile(
+	`^(.*[/-])?(cc|gcc|c\+\+|g\+\+|clang|clang\+\+)(-[0-9.]+)?$`)
+
+var sourceFileRegexp = regexp.MustCompile(`\.(c|C|cc|cpp|cxx|c\+\+)$`)
+
+// Automake uses this construct to locate sources in VPATH builds.
+var vpathSourceRegexp = regexp.MustCompile(
+	"^`test -f '([^']*)' \\|\\| echo '([^']*)'`(.*)$")
+
+// Dependency tracking options are of no use in a compilation
+// database. The value is true for the options with an argument.
+var dependencyTrackingOptions = map[string]bool{
+	"-MD": false, "-MMD": false, "-MP": false,
+	"-MF": true, "-MT": true, "-MQ": true,
+}
+
+// resolveSourceWord returns the pathname of the source file
+// denoted by a word of the compiler command line in 'dir'.
+func resolveSourceWord(dir, word string) string {
+	if match := vpathSourceRegexp.FindStringSubmatch(word); match != nil {
+		source := unquoteShellWord(match[3])
+		if _, err := os.Stat(filepath.Join(dir, source)); err == nil {
+			return source
+		}
+		return match[2] + source
+	}
+	return unquoteShellWord(word)
+}
+
+// compileCommandFromWords returns the compile command contained
+// in a simple shell command, or nil if the command does not compile
+// a C or C++ source file. Compiler invocations that are wrapped in
+// 'libtool --mode=compile' are recognized as well.
+func compileCommandFromWords(dir string, words []string) *compileCommand {
+	start := -1
+	for i, word := range words {
+		if word == "--mode=compile" {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		for i, word := range words {
+			if compilerRegexp.MatchString(word) {
+				start = i
+				break
+			}
+		}
+	}
+	if start < 0 || start >= len(words) ||
+		!compilerRegexp.MatchString(words[start]) {
+		return nil
+	}
+
+	var arguments []string
+	compileOnly := false
+	source := ""
+	sourceIndex := -1
+
+	for i := start; i < len(words); i++ {
+		word := words[i]
+
+		if hasArg, found := dependencyTrackingOptions[word]; found {
+			if hasArg {
+				i++
+			}
+			continue
+		}
+
+		switch {
+		case word == "-c":
+			compileOnly = true
+		case word == "-o" && i+1 < len(words):
+			arguments = append(arguments, word)
+			i++
+			word = words[i]
+		case i > start && !strings.HasPrefix(word, "-") &&
+			sourceFileRegexp.MatchString(word):
+			source = resolveSourceWord(dir, word)
+			sourceIndex = len(arguments)
+			arguments = append(arguments, source)
+			continue
+		}
+
+		arguments = append(arguments, unquoteShellWord(word))
+	}
+
+	if !compileOnly || sourceIndex < 0 {
+		return nil
+	}
+
+	if !filepath.IsAbs(source) {
+		source = filepath.Join(dir, source)
+	}
+	arguments[sourceIndex] = source
+
+	return &compileCommand{dir, arguments, "", source}
+}
+
+var makeDirectoryRegexp = regexp.MustCompile(
+	"^[^ ]*make(\\[[0-9]+\\])?: (Entering|Leaving) directory [`']" +
+		"(.*)'$")
+
+// parseMakeDryRun extracts compile commands from the output
+// of 'make -n -w', which was started in the directory 'topDir'.
+func parseMakeDryRun(output io.Reader, topDir string) ([]compileCommand,
+	error) {
+	var commands []compileCommand
+	dirs := []string{topDir}
+
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(nil, 1024*1024)
+
+	var line string
+
+	for scanner.Scan() {
+		line += scanner.Text()
+
+		// Join the lines of multi-line commands.
+		if strings.HasSuffix(line, "\\") {
+			line = line[:len(line)-1]
+			continue
+		}
+
+		if match := makeDirectoryRegexp.FindStringSubmatch(
+			line); match != nil {
+			if match[2] == "Entering" {
+				dirs = append(dirs, match[3])
+			} else if len(dirs) > 1 {
+				dirs = dirs[:len(dirs)-1]
+			}
+			line = ""
+			continue
+		}
+
+		dir := dirs[len(dirs)-1]
+
+		var words []string
+		for _, word := range append(splitShellWords(line), ";") {
+			switch word {
+			case ";", "&&", "||", "|", "&":
+				cc := compileCommandFromWords(dir, words)
+				if cc != nil {
+					commands = append(commands, *cc)
+				}
+				words = words[:0]
+			default:
+				words = append(words, word)
+			}
+		}
+
+		line = ""
+	}
+
+	return commands, scanner.Err()
+}
+
+// makeDryRunCompileCommands collects the compile commands
+// of a package that has been configured in 'pkgBuildDir'.
+func makeDryRunCompileCommands(pkgBuildDir string) ([]compileCommand,
+	error) {
+	// GNU make runs the recipes that remake the makefiles even
+	// in the dry run mode, which --always-make would trigger
+	// unconditionally. The -o options are not passed to the
+	// recursive make invocations, but the Automake makefiles
+	// pass AM_MAKEFLAGS to them.
+	remakeExclusions := []string{"-o", "Makefile", "-o", "Makefile.in",
+		"-o", "config.status", "-o", "configure", "-o", "aclocal.m4"}
+
+	args := append([]string{"-n", "-w", "--always-make"},
+		remakeExclusions...)
+	args = append(args, "AM_MAKEFLAGS="+
+		strings.Join(remakeExclusions, " "))
+
+	makeCmd := exec.Command("make", args...)
+	makeCmd.Dir = pkgBuildDir
+	makeCmd.Env = append(os.Environ(), "LC_ALL=C")
+	makeCmd.Stderr = os.Stderr
+
+	output, err := makeCmd.Output()
+	if err != nil {
+		return nil, errors.New(pkgBuildDir + ": make -n: " +
+			err.Error())
+	}
+
+	return parseMakeDryRun(bytes.NewReader(output), pkgBuildDir)
+}
+
+// readCompileCommands reads a compilation database
+// that was generated by CMake or Meson.
+func readCompileCommands(pathname string) ([]compileCommand, error) {
+	contents, err := ioutil.ReadFile(pathname)
+	if err != nil {
+		return nil, err
+	}
+
+	var commands []compileCommand
+
+	if err = json.Unmarshal(contents, &commands); err != nil {
+		return nil, errors.New(pathname + ": " + err.Error())
+	}
+
+	return commands, nil
+}
+
+// useRealSourcePathnames replaces the pathnames of the source files
+// that are symbolic links created by linkFilesFromSourceDir with
+// the pathnames of the original source files. Shell commands are
+// split into arguments so that the source pathname in the command
+// line matches the 'file' field.
+func useRealSourcePathnames(commands []compileCommand) {
+	for i := range commands {
+		cc := &commands[i]
+
+		file := cc.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(cc.Directory, file)
+		}
+
+		realFile, err := filepath.EvalSymlinks(file)
+		if err != nil || realFile == file {
+			continue
+		}
+
+		if cc.Command != "" {
+			for _, word := range splitShellWords(cc.Command) {
+				cc.Arguments = append(cc.Arguments,
+					unquoteShellWord(word))
+			}
+			cc.Command = ""
+		}
+
+		for j, arg := range cc.Arguments {
+			if arg == cc.File || arg == file {
+				cc.Arguments[j] = realFile
+			}
+		}
+		cc.File = realFile
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	words := splitShellWords(`a 'b c'&&d="e;f" ` + "`g | h`" + `;i\ j|k`)
+
+	expected := []string{"a", "'b c'", "&&", `d="e;f"`, "`g | h`",
+		";", "i j", "|", "k"}
+
+	if !reflect.DeepEqual(words, expected) {
+		t.Errorf("Unexpected words: %q", words)
+	}
+}
+
+func TestParseMakeDryRun(t *testing.T) {
+	output := `make: Entering directory '/b/pkg'
+make  all-recursive
+make[1]: Entering directory '/b/pkg/src'
+depbase=` + "`echo a.lo | sed 's|.lo$||'`" + `;\
+/bin/bash ../libtool  --tag=CXX   --mode=compile g++ -DHAVE_CONFIG_H -I. -MT a.lo -MD -MP -MF $depbase.Tpo -c -o a.lo ../../p/src/a.cc &&\
+mv -f $depbase.Tpo $depbase.Plo
+gcc -DNAME='"x y"' -c -o b.o ` + "`test -f 'b.c' || echo '../../p/src/'`b.c" + `
+g++ -o prog a.o b.o
+make[1]: Leaving directory '/b/pkg/src'
+echo c.cc
+make: Leaving directory '/b/pkg'
+`
+
+	commands, err := parseMakeDryRun(strings.NewReader(output), "/b/pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []compileCommand{
+		{"/b/pkg/src", []string{"g++", "-DHAVE_CONFIG_H", "-I.",
+			"-c", "-o", "a.lo", "/b/p/src/a.cc"}, "",
+			"/b/p/src/a.cc"},
+		{"/b/pkg/src", []string{"gcc", `-DNAME="x y"`, "-c",
+			"-o", "b.o", "/b/p/src/b.c"}, "", "/b/p/src/b.c"},
+	}
+
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("Unexpected compile commands: %v", commands)
+	}
+}
+
+func TestUseRealSourcePathnames(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "compdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tempDir, err = filepath.EvalSymlinks(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := filepath.Join(tempDir, "a.cc")
+	if err = ioutil.WriteFile(source, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tempDir, "link.cc")
+	if err = os.Symlink(source, link); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := []compileCommand{
+		{tempDir, []string{"cc", "-c", link}, "", link},
+		{tempDir, nil, "cc -c link.cc", "link.cc"},
+	}
+
+	useRealSourcePathnames(commands)
+
+	expected := []compileCommand{
+		{tempDir, []string{"cc", "-c", source}, "", source},
+		{tempDir, []string{"cc", "-c", source}, "", source},
+	}
+
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("Unexpected compile commands: %v", commands)
+	}
+}
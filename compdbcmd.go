@@ -0,0 +1,98 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path"
+
+	"github.com/spf13/cobra"
+)
+
+func generateCompilationDatabase(args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+
+	backend, err := ws.backend()
+	if err != nil {
+		return err
+	}
+
+	pi, err := readPackageDefinitions(ws.wp)
+	if err != nil {
+		return err
+	}
+
+	var selection packageDefinitionList
+
+	if len(args) > 0 {
+		selection, err = packageRangesToFlatSelection(pi, args)
+	} else {
+		selection, err = readPackageSelection(pi, ws.absPrivateDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	commands, err := backend.compileCommands(ws, selection)
+	if err != nil {
+		return err
+	}
+	if commands == nil {
+		commands = []compileCommand{}
+	}
+
+	useRealSourcePathnames(commands)
+
+	compdb, err := json.MarshalIndent(commands, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	compdbPathname := path.Join(ws.absDir, compdbFilename)
+
+	err = ioutil.WriteFile(compdbPathname, append(compdb, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+
+	if !flags.quiet {
+		fmt.Printf("%s: %d compile commands\n",
+			ws.relativeToWorkspace(compdbPathname), len(commands))
+	}
+
+	return nil
+}
+
+// compdbCmd represents the compdb command
+var compdbCmd = &cobra.Command{
+	Use:   "compdb [package_range...]",
+	Short: "Generate a compilation database for the workspace",
+	Long: wrapText("Collect the compile commands of the selected " +
+		"packages (or the specified package range), which must " +
+		"have been configured, and write them to '" +
+		compdbFilename + "' in the workspace directory. The " +
+		"pathnames of the source files refer to the original " +
+		"sources rather than to their links in the generated " +
+		"packages."),
+	Run: func(_ *cobra.Command, args []string) {
+		if err := generateCompilationDatabase(args); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(compdbCmd)
+
+	compdbCmd.Flags().SortFlags = false
+	addQuietFlag(compdbCmd)
+	addWorkspaceDirFlag(compdbCmd)
+}
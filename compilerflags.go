@@ -0,0 +1,88 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// supportedCXXStandards lists the values accepted by
+// the bundled AX_CXX_COMPILE_STDCXX macro.
+var supportedCXXStandards = []string{"11", "14", "17", "20"}
+
+// warningProfiles maps warning profile names to
+// the respective GNU C++ compiler options.
+var warningProfiles = map[string]string{
+	"none":    "",
+	"default": "-Wall -Wextra",
+	"strict": "-Wall -Wextra -pedantic -Wshadow -Wpointer-arith " +
+		"-Wcast-qual -Wwrite-strings -Wconversion -Wsign-compare " +
+		"-Wredundant-decls -Woverloaded-virtual -Wsign-promo",
+}
+
+// normalizeCXXStd converts the 'cxx_std' parameter to a string
+// and makes sure that it names a supported C++ standard.
+func normalizeCXXStd(pathname string, params templateParams) error {
+	value := params["cxx_std"]
+	if value == nil {
+		return nil
+	}
+
+	cxxStd := strings.TrimPrefix(strings.ToLower(fmt.Sprint(value)), "c++")
+
+	for _, supported := range supportedCXXStandards {
+		if cxxStd == supported {
+			params["cxx_std"] = cxxStd
+			return nil
+		}
+	}
+
+	return errors.New(pathname + ": unsupported 'cxx_std' value '" +
+		fmt.Sprint(value) + "' (must be one of " +
+		strings.Join(supportedCXXStandards, ", ") + ")")
+}
+
+// normalizeWarnings converts the 'warnings' parameter, which can be
+// either a profile name or a list of compiler options, to the
+// 'warning_flags' string parameter used by the templates.
+func normalizeWarnings(pathname string, params templateParams) error {
+	value := params["warnings"]
+	if value == nil {
+		params["warning_flags"] = warningProfiles["default"]
+		return nil
+	}
+
+	if profile, ok := value.(string); ok {
+		if flags, found := warningProfiles[profile]; found {
+			params["warning_flags"] = flags
+			return nil
+		}
+	}
+
+	flags, err := getStringListField(pathname, value, "warnings")
+	if err != nil {
+		return err
+	}
+
+	params["warning_flags"] = strings.Join(flags, " ")
+	return nil
+}
+
+// normalizeVisibility validates the 'visibility' parameter, which
+// defines whether library symbols are exported by default. Hidden
+// visibility must be requested explicitly.
+func normalizeVisibility(pathname string, params templateParams) error {
+	switch value := params["visibility"]; value {
+	case nil:
+		params["visibility"] = "default"
+	case "default", "hidden":
+	default:
+		return errors.New(pathname + ": 'visibility' must be " +
+			"either 'default' or 'hidden'")
+	}
+	return nil
+}
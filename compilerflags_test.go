@@ -0,0 +1,66 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+)
+
+func TestCXXStd(t *testing.T) {
+	for value, expected := range map[interface{}]string{
+		11: "11", "14": "14", "c++17": "17", "C++20": "20"} {
+		params := templateParams{"cxx_std": value}
+		if err := normalizeCXXStd("test.yaml", params); err != nil {
+			t.Error(err)
+		} else if params["cxx_std"] != expected {
+			t.Error("Unexpected 'cxx_std' value:", params["cxx_std"])
+		}
+	}
+
+	if normalizeCXXStd("test.yaml", templateParams{"cxx_std": 98}) == nil {
+		t.Error("Unsupported C++ standard was accepted")
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	for value, expected := range map[interface{}]string{
+		"none":  "",
+		"-Wall": "-Wall",
+		nil:     warningProfiles["default"],
+	} {
+		params := templateParams{"warnings": value}
+		if err := normalizeWarnings("test.yaml", params); err != nil {
+			t.Error(err)
+		} else if params["warning_flags"] != expected {
+			t.Error("Unexpected warning flags:",
+				params["warning_flags"])
+		}
+	}
+
+	params := templateParams{"warnings": []interface{}{"-Wall", "-Werror"}}
+	if err := normalizeWarnings("test.yaml", params); err != nil {
+		t.Error(err)
+	} else if params["warning_flags"] != "-Wall -Werror" {
+		t.Error("Custom warning list was not joined")
+	}
+}
+
+func TestVisibility(t *testing.T) {
+	for value, expected := range map[interface{}]string{
+		nil: "default", "default": "default", "hidden": "hidden"} {
+		params := templateParams{"visibility": value}
+		if err := normalizeVisibility("test.yaml", params); err != nil {
+			t.Error(err)
+		} else if params["visibility"] != expected {
+			t.Error("Unexpected 'visibility' value:",
+				params["visibility"])
+		}
+	}
+
+	if normalizeVisibility("test.yaml",
+		templateParams{"visibility": "protected"}) == nil {
+		t.Error("Unsupported visibility was accepted")
+	}
+}
@@ -113,17 +113,8 @@ func configurePackage(installDir, pkgRootDir string, pd *packageDefinition,
 	return nil
 }
 
-func configurePackages(args []string) error {
-	ws, err := loadWorkspace()
-	if err != nil {
-		return err
-	}
-
-	pi, err := readPackageDefinitions(ws.wp)
-	if err != nil {
-		return err
-	}
-
+func (autotoolsBackend) configurePackages(ws *workspace, pi *packageIndex,
+	selection packageDefinitionList, conftab *Conftab) error {
 	buildDir := ws.buildDir()
 
 	cfgEnv := prepareConfigureEnv(buildDir)
@@ -136,6 +127,40 @@ func configurePackages(args []string) error {
 		}
 	}
 
+	for _, pd := range selection {
+		cfgEnv.addPackageBuildDir(pd.PackageName)
+	}
+
+	installDir := ws.installDir()
+	pkgRootDir := ws.generatedPkgRootDir()
+
+	for _, pd := range selection {
+		err := configurePackage(installDir, pkgRootDir, pd,
+			cfgEnv, conftab)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func configurePackages(args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+
+	backend, err := ws.backend()
+	if err != nil {
+		return err
+	}
+
+	pi, err := readPackageDefinitions(ws.wp)
+	if err != nil {
+		return err
+	}
+
 	var selection packageDefinitionList
 
 	if len(args) > 0 {
@@ -147,28 +172,13 @@ func configurePackages(args []string) error {
 		return err
 	}
 
-	for _, pd := range selection {
-		cfgEnv.addPackageBuildDir(pd.PackageName)
-	}
-
 	conftab, err := readConftab(
 		path.Join(ws.absPrivateDir, conftabFilename))
 	if err != nil {
 		return err
 	}
 
-	installDir := ws.installDir()
-	pkgRootDir := ws.generatedPkgRootDir()
-
-	for _, pd := range selection {
-		err := configurePackage(installDir, pkgRootDir, pd,
-			cfgEnv, conftab)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return backend.configurePackages(ws, pi, selection, conftab)
 }
 
 // configureCmd represents the configure command
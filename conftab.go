@@ -237,6 +237,21 @@ func (conftab *Conftab) getConfigureArgs(pkgName string) []string {
 	return args
 }
 
+// getOptionDefinition returns the definition of the option in the
+// section of the package, or in the global section if the option is
+// not defined in the package section. An empty string is returned
+// if the option is not defined in either section.
+func (conftab *Conftab) getOptionDefinition(pkgName string,
+	key optionKey) string {
+	if section, found := conftab.sectionByPackageName[pkgName]; found {
+		if definition := section.options[key]; definition != "" {
+			return definition
+		}
+	}
+
+	return conftab.GlobalSection.options[key]
+}
+
 type sectionChange struct {
 	deleted, added string
 }
@@ -18,9 +18,17 @@ import (
 
 type templateParams map[string]interface{}
 
+// matchAny returns true if the pathname matches at least one of
+// the patterns. Patterns that contain a slash are matched against
+// the whole pathname using globMatch(); all other patterns are
+// matched against its base name.
 func matchAny(pathname string, patterns []string) bool {
 	for _, pattern := range patterns {
-		if match, _ := filepath.Match(pattern,
+		if strings.Contains(pattern, "/") {
+			if globMatch(pattern, pathname) {
+				return true
+			}
+		} else if match, _ := filepath.Match(pattern,
 			filepath.Base(pathname)); match {
 			return true
 		}
@@ -28,6 +36,83 @@ func matchAny(pathname string, patterns []string) bool {
 	return false
 }
 
+// matchPathComponents matches pathname components against pattern
+// components. A pattern component consisting of two asterisks
+// matches zero or more pathname components.
+func matchPathComponents(pattern, components []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(components); i >= 0; i-- {
+				if matchPathComponents(pattern[1:],
+					components[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(components) == 0 {
+			return false
+		}
+
+		if match, _ := filepath.Match(pattern[0],
+			components[0]); !match {
+			return false
+		}
+
+		pattern, components = pattern[1:], components[1:]
+	}
+
+	return len(components) == 0
+}
+
+// globMatch reports whether the relative pathname matches the
+// pattern, which may contain the '**' wildcard in addition to
+// the wildcards supported by filepath.Match().
+func globMatch(pattern, pathname string) bool {
+	return matchPathComponents(strings.Split(pattern, "/"),
+		strings.Split(pathname, "/"))
+}
+
+// maxReadFileSize limits the size of files that
+// templates can read with the ReadFile function.
+const maxReadFileSize = 64 * 1024
+
+// packageRelativePathname validates a pathname passed to one of
+// the filesystem template functions and returns its clean form.
+func packageRelativePathname(pathname string) (string, error) {
+	cleanPathname := path.Clean(pathname)
+	if path.IsAbs(cleanPathname) || cleanPathname == ".." ||
+		strings.HasPrefix(cleanPathname, "../") {
+		return "", errors.New(pathname +
+			": pathname must be relative to the package directory")
+	}
+	return cleanPathname, nil
+}
+
+// resolvePackageFile returns the pathname of a file with all symbolic
+// links resolved. The resolved pathname must still be inside the
+// package directory; this prevents templates from reading files
+// outside of the package through symbolic links.
+func resolvePackageFile(packageDir, pathname string) (string, error) {
+	realPackageDir, err := filepath.EvalSymlinks(packageDir)
+	if err != nil {
+		return "", err
+	}
+	realPathname, err := filepath.EvalSymlinks(
+		filepath.Join(packageDir, pathname))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(realPackageDir, realPathname)
+	if err != nil || rel == ".." || strings.HasPrefix(rel,
+		".."+string(filepath.Separator)) {
+		return "", errors.New(pathname +
+			": file is outside of the package directory")
+	}
+	return realPathname, nil
+}
+
 func filterPathnames(pathnames, patterns []string, invert bool) []string {
 	var filtered []string
 
@@ -40,30 +125,66 @@ func filterPathnames(pathnames, patterns []string, invert bool) []string {
 	return filtered
 }
 
+// varName converts its argument to a valid shell or C identifier.
+func varName(arg string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' ||
+			r >= '0' && r <= '9' {
+			return r
+		} else if r == '+' {
+			return 'x'
+		}
+		return '_'
+	}, arg)
+}
+
+// varNameUC converts its argument to an uppercase identifier.
+func varNameUC(arg string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		} else if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		} else if r == '+' {
+			return 'X'
+		}
+		return '_'
+	}, arg)
+}
+
+// splitVersion returns the first three numeric components of
+// a version string, in which the components are separated by
+// either dots or colons. Missing components are reported as zeros.
+func splitVersion(version interface{}) []string {
+	components := strings.FieldsFunc(fmt.Sprint(version),
+		func(r rune) bool {
+			return r == '.' || r == ':'
+		})
+
+	result := []string{"0", "0", "0"}
+
+	for i := 0; i < len(components) && i < len(result); i++ {
+		digits := strings.TrimLeftFunc(components[i],
+			func(r rune) bool {
+				return r < '0' || r > '9'
+			})
+		end := strings.IndexFunc(digits, func(r rune) bool {
+			return r < '0' || r > '9'
+		})
+		if end >= 0 {
+			digits = digits[:end]
+		}
+		if digits != "" {
+			result[i] = digits
+		}
+	}
+
+	return result
+}
+
 var commonFuncMap = template.FuncMap{
-	"VarName": func(arg string) string {
-		return strings.Map(func(r rune) rune {
-			if r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' ||
-				r >= '0' && r <= '9' {
-				return r
-			} else if r == '+' {
-				return 'x'
-			}
-			return '_'
-		}, arg)
-	},
-	"VarNameUC": func(arg string) string {
-		return strings.Map(func(r rune) rune {
-			if r >= 'a' && r <= 'z' {
-				return r - 'a' + 'A'
-			} else if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
-				return r
-			} else if r == '+' {
-				return 'X'
-			}
-			return '_'
-		}, arg)
-	},
+	"VarName":   varName,
+	"VarNameUC": varNameUC,
 	"LibName": func(arg string) string {
 		return strings.Map(func(r rune) rune {
 			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
@@ -77,6 +198,7 @@ var commonFuncMap = template.FuncMap{
 	"TrimExt": func(filename string) string {
 		return filename[:len(filename)-len(filepath.Ext(filename))]
 	},
+	"SplitVersion": splitVersion,
 	"StringList": func(elem ...string) []string {
 		return elem
 	},
@@ -86,6 +208,20 @@ var commonFuncMap = template.FuncMap{
 	"Exclude": func(pathnames, patterns []string) []string {
 		return filterPathnames(pathnames, patterns, true)
 	},
+	"MesonString": func(text string) string {
+		return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'",
+			"\n", "\\n").Replace(text) + "'"
+	},
+	// Doxygen only recognizes the \" escape sequence in quoted
+	// strings, which cannot span multiple lines. A backslash at
+	// the end of the value would escape the closing quote.
+	"DoxygenString": func(text string) string {
+		text = strings.Join(strings.Fields(text), " ")
+		if strings.HasSuffix(text, "\\") {
+			text += " "
+		}
+		return "\"" + strings.Replace(text, "\"", "\\\"", -1) + "\""
+	},
 	"Comment": func(text string) string {
 		var result string
 
@@ -148,8 +284,8 @@ func parseAndExecuteTemplate(templateName string, templateContents []byte,
 var templateErrorMarker = "AFTMPLERR"
 
 func executePackageFileTemplate(templateName string,
-	templateContents []byte, pd *packageDefinition,
-	dirTree *directoryTree,
+	templateContents []byte, definitions map[string]string,
+	pd *packageDefinition, pi *packageIndex, dirTree *directoryTree,
 	fileParams []outputFileParams) ([]filenameAndContents, error) {
 
 	funcMap := template.FuncMap{
@@ -162,10 +298,79 @@ func executePackageFileTemplate(templateName string,
 				return st.list()
 			}
 			return nil
+		},
+		"Glob": func(pattern string) []string {
+			var matches []string
+			for _, pathname := range dirTree.list() {
+				if globMatch(pattern, pathname) {
+					matches = append(matches, pathname)
+				}
+			}
+			return matches
+		},
+		"Exists": func(pathname string) (bool, error) {
+			pathname, err := packageRelativePathname(pathname)
+			if err != nil {
+				return false, err
+			}
+			return pathname == "." || dirTree.hasFile(pathname) ||
+				dirTree.subtree(pathname) != nil, nil
+		},
+		"ReadFile": func(pathname string) (string, error) {
+			pathname, err := packageRelativePathname(pathname)
+			if err != nil {
+				return "", err
+			}
+			if !dirTree.hasFile(pathname) {
+				return "", errors.New(pathname +
+					": no such file in package " +
+					pd.PackageName)
+			}
+			pathname, err = resolvePackageFile(
+				filepath.Dir(pd.pathname), pathname)
+			if err != nil {
+				return "", err
+			}
+			info, err := os.Stat(pathname)
+			if err != nil {
+				return "", err
+			}
+			if info.Size() > maxReadFileSize {
+				return "", fmt.Errorf("%s: file is larger "+
+					"than %d bytes", pathname,
+					maxReadFileSize)
+			}
+			contents, err := ioutil.ReadFile(pathname)
+			return string(contents), err
+		},
+		"OptionalSources": func(sources []string,
+			options []*configureOption) ([]string, error) {
+			result, err := optionalSources(sources, options)
+			if err != nil {
+				return nil, errors.New(templateErrorMarker +
+					pd.PackageName + ": " + err.Error())
+			}
+			return result, nil
+		},
+		"Package": func(pkgName string) (templateParams, error) {
+			dep, err := pi.getPackageByName(pkgName)
+			if err != nil {
+				return nil, err
+			}
+			return dep.params, nil
+		},
+		"Requires": func() packageDefinitionList {
+			return pd.required
+		},
+		"AllRequires": func() packageDefinitionList {
+			return pd.allRequired
+		},
+		"Dependents": func() packageDefinitionList {
+			return pd.dependent
 		}}
 
 	return parseAndExecuteTemplate(templateName, templateContents,
-		funcMap, commonDefinitions, fileParams)
+		funcMap, definitions, fileParams)
 }
 
 func writeGeneratedFiles(targetDir string, outputFiles []filenameAndContents,
@@ -222,11 +427,12 @@ func writeGeneratedFiles(targetDir string, outputFiles []filenameAndContents,
 
 func generateFilesFromProjectFileTemplate(projectDir, templateName string,
 	templateContents []byte, templateFileMode os.FileMode,
-	pd *packageDefinition, dirTree *directoryTree,
+	definitions map[string]string, pd *packageDefinition,
+	pi *packageIndex, dirTree *directoryTree,
 	fileParams []outputFileParams) (bool, error) {
 
 	outputFiles, err := executePackageFileTemplate(templateName,
-		templateContents, pd, dirTree, fileParams)
+		templateContents, definitions, pd, pi, dirTree, fileParams)
 
 	if err != nil {
 		if err, ok := err.(template.ExecError); ok {
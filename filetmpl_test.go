@@ -5,6 +5,10 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -27,4 +31,144 @@ func TestTemplateFunctions(t *testing.T) {
 
 	runTemplateFunctionTest(t, "LibName", "libc++11", "libc++11")
 	runTemplateFunctionTest(t, "LibName", "dash-dot.", "dash-dot.")
+
+	runTemplateFunctionTest(t, "MesonString", "it's", `'it\'s'`)
+	runTemplateFunctionTest(t, "MesonString", `a\b`, `'a\\b'`)
+
+	runTemplateFunctionTest(t, "DoxygenString", `say "hi"`, `"say \"hi\""`)
+	runTemplateFunctionTest(t, "DoxygenString", "a\\\nb\\", `"a\ b\ "`)
+}
+
+func TestMatchAny(t *testing.T) {
+	patterns := []string{"*.cc", "sub/*.c", "opt/**/*.h"}
+
+	for pathname, expected := range map[string]bool{
+		"a.cc":       true,
+		"sub/b.cc":   true,
+		"sub/c.c":    true,
+		"c.c":        false,
+		"other/d.c":  false,
+		"sub/deep/e": false,
+		"opt/a/b.h":  true,
+		"opt/c.h":    true,
+	} {
+		if matchAny(pathname, patterns) != expected {
+			t.Error("Unexpected match result for " + pathname)
+		}
+	}
+}
+
+func TestSplitVersion(t *testing.T) {
+	for version, expected := range map[string]string{
+		"1.2.3":     "1 2 3",
+		"2.5":       "2 5 0",
+		"3.0.1-rc1": "3 0 1",
+		"4:1:2":     "4 1 2",
+		"v1.2":      "1 2 0",
+	} {
+		result := strings.Join(splitVersion(version), " ")
+		if result != expected {
+			t.Error("Error: \"" + result + "\" != \"" +
+				expected + "\"")
+		}
+	}
+}
+
+func TestCrossPackageFunctions(t *testing.T) {
+	pi, err := makePackageIndexForTesting([]string{
+		"d:b,c", "b:a", "c:a", "a"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pi.packageByName["a"].params = templateParams{"version": "1.0"}
+
+	outputFiles, err := executePackageFileTemplate("test",
+		[]byte(`{{range Requires}}{{.PackageName}} {{end}}|`+
+			`{{range AllRequires}}{{.PackageName}} {{end}}|`+
+			`{{range Dependents}}{{.PackageName}} {{end}}|`+
+			`{{(Package "a").version}}`),
+		commonDefinitions, pi.packageByName["b"], pi,
+		newDirectoryTree(),
+		[]outputFileParams{{"test", templateParams{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "a |a |d |1.0"
+	if result := string(outputFiles[0].contents); result != expected {
+		t.Error("Error: \"" + result + "\" != \"" + expected + "\"")
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	for pattern, expected := range map[string]bool{
+		"src/**/*.cc":  true,
+		"src/*/*.cc":   true,
+		"src/*.cc":     false,
+		"**/*.cc":      true,
+		"**":           true,
+		"src/**":       true,
+		"src/**/b/*.h": false,
+	} {
+		if globMatch(pattern, "src/a/b.cc") != expected {
+			t.Error("Unexpected match result for " + pattern)
+		}
+	}
+
+	if !globMatch("src/**/*.cc", "src/b.cc") {
+		t.Error("'**' must match zero pathname components")
+	}
+}
+
+func TestPackageRelativePathname(t *testing.T) {
+	for _, pathname := range []string{"/etc/passwd", "..", "a/../../b"} {
+		if _, err := packageRelativePathname(pathname); err == nil {
+			t.Error("Pathname " + pathname + " was not rejected")
+		}
+	}
+
+	if cleanPathname, err := packageRelativePathname(
+		"./src//a.cc"); err != nil || cleanPathname != "src/a.cc" {
+		t.Error("Unexpected result for a valid pathname")
+	}
+}
+
+func TestResolvePackageFile(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "readfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	packageDir := filepath.Join(tempDir, "pkg")
+	if err = os.Mkdir(packageDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	outside := filepath.Join(tempDir, "secret.txt")
+	inside := filepath.Join(packageDir, "a.txt")
+	for _, pathname := range []string{outside, inside} {
+		if err = ioutil.WriteFile(pathname, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = os.Symlink(outside, filepath.Join(packageDir,
+		"escape.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Symlink("a.txt", filepath.Join(packageDir,
+		"b.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = resolvePackageFile(packageDir, "escape.txt"); err == nil {
+		t.Error("Symbolic link to a file outside of the package " +
+			"was not rejected")
+	}
+
+	if _, err = resolvePackageFile(packageDir, "b.txt"); err != nil {
+		t.Error(err)
+	}
 }
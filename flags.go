@@ -20,6 +20,9 @@ var flags = struct {
 	buildDir          string
 	installDir        string
 	noBootstrap       bool
+	testReport        string
+	backend           string
+	generator         string
 }{}
 
 func addQuietFlag(c *cobra.Command) {
@@ -84,3 +87,21 @@ func addNoBootstrapFlag(c *cobra.Command) {
 		"do not bootstrap packages ("+conftabFilename+
 			" will not be updated)")
 }
+
+func addTestReportFlag(c *cobra.Command) {
+	c.Flags().StringVarP(&flags.testReport, "output", "o", "",
+		"pathname of the JUnit XML file "+
+			"(default \""+testReportFilename+"\")")
+}
+
+func addBackendFlag(c *cobra.Command) {
+	c.Flags().StringVar(&flags.backend, "backend", "",
+		"build system to generate: "+strings.Join(backendNames(), ", ")+
+			" (default \""+defaultBackendName+"\")")
+}
+
+func addGeneratorFlag(c *cobra.Command) {
+	c.Flags().StringVar(&flags.generator, "generator", "",
+		"meta build file generator: "+generatorNames+
+			" (default \""+makeGenerator+"\")")
+}
@@ -110,8 +110,19 @@ func (helpParser *configureHelpParser) parseOptions(packageDir string) (
 	return options, nil
 }
 
-func generateAndBootstrapPackages(ws *workspace, pi *packageIndex,
-	selection packageDefinitionList, conftab *Conftab) error {
+// generatedPackage describes a package whose build
+// files have been generated by generatePackageFiles.
+type generatedPackage struct {
+	pd         *packageDefinition
+	packageDir string
+	changed    bool
+}
+
+// generatePackageFiles generates the build files for the selected
+// packages using the package generators of the specified backend.
+func generatePackageFiles(backend buildBackend, ws *workspace,
+	pi *packageIndex, selection packageDefinitionList) (
+	[]generatedPackage, error) {
 	pkgRootDir := ws.generatedPkgRootDir()
 
 	type packageAndGenerator struct {
@@ -123,37 +134,73 @@ func generateAndBootstrapPackages(ws *workspace, pi *packageIndex,
 	var packagesAndGenerators []packageAndGenerator
 
 	for _, pd := range selection {
+		if pd.isLibrary() && !flags.quiet {
+			if err := warnAboutPrivateIncludes(pd); err != nil {
+				return nil, err
+			}
+		}
+
 		packageDir := path.Join(pkgRootDir, pd.PackageName)
 
-		generator, err := pd.getPackageGeneratorFunc(packageDir)
+		generator, err := backend.packageGeneratorFunc(pd,
+			packageDir, pi)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		packagesAndGenerators = append(packagesAndGenerators,
 			packageAndGenerator{pd, packageDir, generator})
 	}
 
-	var packagesToBootstrap []packageAndGenerator
+	var generatedPackages []generatedPackage
 
-	// Generate autoconf and automake sources for the selected packages.
 	for _, pg := range packagesAndGenerators {
 		changed, err := pg.generator()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		_, err = os.Stat(path.Join(pg.packageDir, "configure"))
+		generatedPackages = append(generatedPackages,
+			generatedPackage{pg.pd, pg.packageDir, changed})
+	}
+
+	return generatedPackages, nil
+}
+
+func generateAndBootstrapPackages(ws *workspace, pi *packageIndex,
+	selection packageDefinitionList, conftab *Conftab) error {
+	backend, err := ws.backend()
+	if err != nil {
+		return err
+	}
+
+	return backend.generateAndBootstrapPackages(ws, pi, selection,
+		conftab)
+}
+
+func (b autotoolsBackend) generateAndBootstrapPackages(ws *workspace,
+	pi *packageIndex, selection packageDefinitionList,
+	conftab *Conftab) error {
+	// Generate autoconf and automake sources for the selected packages.
+	generatedPackages, err := generatePackageFiles(b, ws, pi, selection)
+	if err != nil {
+		return err
+	}
+
+	var packagesToBootstrap []generatedPackage
+
+	for _, gp := range generatedPackages {
+		_, err = os.Stat(path.Join(gp.packageDir, "configure"))
 
-		if changed || os.IsNotExist(err) {
-			packagesToBootstrap = append(packagesToBootstrap, pg)
+		if gp.changed || os.IsNotExist(err) {
+			packagesToBootstrap = append(packagesToBootstrap, gp)
 		}
 	}
 
 	if !flags.noBootstrap {
 		// Bootstrap the selected packages.
-		for _, pg := range packagesToBootstrap {
-			err := bootstrapPackage(pg.packageDir, pg.pd)
+		for _, gp := range packagesToBootstrap {
+			err := bootstrapPackage(gp.packageDir, gp.pd)
 			if err != nil {
 				return err
 			}
@@ -161,8 +208,8 @@ func generateAndBootstrapPackages(ws *workspace, pi *packageIndex,
 
 		helpParser := createConfigureHelpParser()
 
-		for _, pg := range packagesAndGenerators {
-			options, err := helpParser.parseOptions(pg.packageDir)
+		for _, gp := range generatedPackages {
+			options, err := helpParser.parseOptions(gp.packageDir)
 			if err != nil {
 				return err
 			}
@@ -170,11 +217,11 @@ func generateAndBootstrapPackages(ws *workspace, pi *packageIndex,
 			for _, opt := range options {
 				if opt.key.optType != optOther {
 					conftab.addOption(
-						pg.pd.PackageName, &opt)
+						gp.pd.PackageName, &opt)
 				}
 			}
 		}
 	}
 
-	return generateWorkspaceFiles(ws, pi, selection, conftab)
+	return generateWorkspaceFiles(ws, b, pi, selection, conftab)
 }
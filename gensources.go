@@ -0,0 +1,130 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// generatedSource describes an entry of the 'generated_sources'
+// list, which is a rule for generating source files at build time.
+type generatedSource struct {
+	Command string   // Shell command that generates the outputs
+	Inputs  []string // Source files that the command reads
+	Outputs []string // Files that the command generates
+}
+
+// FirstOutput returns the output that is used as the target
+// of the rule. The rest of the outputs depend on this target,
+// which makes sure that the command runs only once.
+func (gs *generatedSource) FirstOutput() string {
+	return gs.Outputs[0]
+}
+
+// OtherOutputs returns all outputs except the first one.
+func (gs *generatedSource) OtherOutputs() []string {
+	return gs.Outputs[1:]
+}
+
+// relativeTo returns a copy of the rule with the pathnames relative
+// to 'dir', which is a subdirectory of src/. The second return value
+// is false if not all outputs of the rule are inside 'dir'.
+func (gs *generatedSource) relativeTo(dir string) (*generatedSource, bool) {
+	prefix := dir + "/"
+
+	rule := &generatedSource{Command: gs.Command}
+
+	for _, output := range gs.Outputs {
+		if !strings.HasPrefix(output, prefix) {
+			return nil, false
+		}
+		rule.Outputs = append(rule.Outputs,
+			strings.TrimPrefix(output, prefix))
+	}
+
+	for _, input := range gs.Inputs {
+		if strings.HasPrefix(input, prefix) {
+			rule.Inputs = append(rule.Inputs,
+				strings.TrimPrefix(input, prefix))
+		} else {
+			rule.Inputs = append(rule.Inputs, "../"+input)
+		}
+	}
+
+	return rule, true
+}
+
+// parseGeneratedSources replaces the 'generated_sources' list in
+// 'params' with a list of generatedSource structures. It also sets
+// the 'generated_inputs' and 'generated_outputs' parameters to the
+// lists of all inputs and all outputs of those rules.
+func parseGeneratedSources(pathname string, params templateParams) error {
+	var allInputs, allOutputs []string
+
+	if value := params["generated_sources"]; value != nil {
+		list, ok := value.([]interface{})
+		if !ok {
+			return errors.New(pathname +
+				": 'generated_sources' must be a list")
+		}
+
+		var rules []*generatedSource
+
+		for _, elem := range list {
+			entry, ok := elem.(map[interface{}]interface{})
+			if !ok {
+				return errors.New(pathname + ": each entry " +
+					"in 'generated_sources' must be a map")
+			}
+
+			entryParams := templateParams{}
+			for key, val := range entry {
+				if keyStr, ok := key.(string); ok {
+					entryParams[keyStr] = val
+				}
+			}
+
+			command, err := getRequiredStringField(pathname,
+				entryParams, "command")
+			if err != nil {
+				return err
+			}
+
+			rule := &generatedSource{Command: command}
+
+			rule.Inputs, err = getStringListField(pathname,
+				entryParams["inputs"],
+				"generated_sources: inputs")
+			if err != nil {
+				return err
+			}
+
+			rule.Outputs, err = getStringListField(pathname,
+				entryParams["outputs"],
+				"generated_sources: outputs")
+			if err != nil {
+				return err
+			}
+			if len(rule.Outputs) == 0 {
+				return errors.New(pathname + ": generated " +
+					"source rule '" + command +
+					"' has no outputs")
+			}
+
+			allInputs = append(allInputs, rule.Inputs...)
+			allOutputs = append(allOutputs, rule.Outputs...)
+
+			rules = append(rules, rule)
+		}
+
+		params["generated_sources"] = rules
+	}
+
+	params["generated_inputs"] = append([]string{}, allInputs...)
+	params["generated_outputs"] = append([]string{}, allOutputs...)
+
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGeneratedSources(t *testing.T) {
+	params := templateParams{"generated_sources": []interface{}{
+		map[interface{}]interface{}{
+			"command": "./gen.sh",
+			"inputs":  "table.in",
+			"outputs": []interface{}{"table.cc", "table.h"}}}}
+
+	if err := parseGeneratedSources("test.yaml", params); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := params["generated_sources"].([]*generatedSource)
+
+	if len(rules) != 1 || rules[0].FirstOutput() != "table.cc" ||
+		!reflect.DeepEqual(rules[0].OtherOutputs(),
+			[]string{"table.h"}) {
+		t.Error("Unexpected generated source rules")
+	}
+
+	if !reflect.DeepEqual(params["generated_inputs"],
+		[]string{"table.in"}) {
+		t.Error("Unexpected list of inputs")
+	}
+}
+
+func TestGeneratedSourceWithoutOutputs(t *testing.T) {
+	params := templateParams{"generated_sources": []interface{}{
+		map[interface{}]interface{}{"command": "true"}}}
+
+	if parseGeneratedSources("test.yaml", params) == nil {
+		t.Error("A rule without outputs was accepted")
+	}
+}
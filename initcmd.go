@@ -48,9 +48,17 @@ func initWorkspace() error {
 		return err
 	}
 
+	if _, err = getBackend(flags.backend); err != nil {
+		return err
+	}
+
+	if err = checkGenerator(flags.generator); err != nil {
+		return err
+	}
+
 	wp := workspaceParams{flags.quiet, pkgpath,
 		flags.makefile, flags.defaultMakeTarget,
-		buildDir, installDir}
+		buildDir, installDir, flags.backend, flags.generator}
 
 	out, err := yaml.Marshal(&wp)
 	if err != nil {
@@ -98,4 +106,6 @@ func init() {
 	addDefaultMakeTargetFlag(initCmd)
 	addBuildDirFlag(initCmd)
 	addInstallDirFlag(initCmd)
+	addBackendFlag(initCmd)
+	addGeneratorFlag(initCmd)
 }
@@ -7,11 +7,17 @@ package main
 var libTemplate = []embeddedTemplateFile{
 	{"include/{name}/Makefile.am", 0644,
 		[]byte(`{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
 pkgincludedir = $(includedir)/{{.name}}
 
 {{$headerExt := StringList "*?.H" "*?.h" "*?.hh" "*?.hxx" "*?.hpp" -}}
+{{if .headers}}{{$headerExt = .headers}}{{end -}}
 {{$allFiles := Dir .dirname -}}
 pkginclude_HEADERS ={{template "Multiline" Select $allFiles $headerExt}}
+{{- if not (Exists (print .dirname "/export.h"))}} \
+	export.h{{end}}
+{{- if not (Exists (print .dirname "/version.h"))}} \
+	version.h{{end}}
 {{$extraFiles := Exclude $allFiles $headerExt}}{{if $extraFiles}}
 EXTRA_DIST ={{template "Multiline" $extraFiles}}
 {{end}}
@@ -36,30 +42,87 @@ uninstall-local:
 	rmdir "$(DESTDIR)$(pkgincludedir)" || true
 
 CLEANFILES = config.h
-{{template "Snippet" .}}`)},
+{{template "SnippetBottom" .}}`)},
 	{"include/Makefile.am", 0644,
 		[]byte(`{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
 SUBDIRS = {{.name}}
-{{template "Snippet" .}}`)},
+{{template "SnippetBottom" .}}`)},
 	{"src/Makefile.am", 0644,
 		[]byte(`{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+{{$hasSymbolsFile := Exists "symbols.txt" -}}
 lib_LTLIBRARIES = lib{{.name}}.la
 
-{{if index . "version-info" -}}
+{{if .version_info -}}
 lib{{VarName .name}}_la_LDFLAGS = -version-info @library_version_info@
 
 {{end -}}
-{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" -}}
+{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" "*?.l" "*?.ll" "*?.y" "*?.yy" -}}
+{{if .sources}}{{$sourceExt = .sources}}{{end -}}
 {{$allFiles := Dir .dirname -}}
-lib{{VarName .name -}}
-_la_SOURCES ={{template "Multiline" Select $allFiles $sourceExt}}
-{{$extraFiles := Exclude $allFiles $sourceExt -}}
-{{if $extraFiles}}
-EXTRA_DIST ={{template "Multiline" $extraFiles}}
+{{$sources := Select $allFiles $sourceExt -}}
+{{$optionalSources := OptionalSources $sources .options -}}
+{{$prefix := print "lib" (VarName .name) -}}
+{{$prefix}}_la_SOURCES ={{template "Multiline" Exclude $sources .optional_source_patterns}}
+{{range .options}}{{$conditional := .Conditional -}}
+{{with Select $optionalSources .Sources}}
+if {{$conditional}}
+{{$prefix}}_la_SOURCES +={{template "Multiline" .}}
+endif
+{{end}}{{end -}}
+{{with .generated_outputs}}
+nodist_{{$prefix}}_la_SOURCES ={{template "Multiline" .}}
 {{end -}}
-{{template "Snippet" .}}`)},
+{{template "GeneratedSources" .generated_sources -}}
+{{$extraFiles := Exclude (Exclude $allFiles $sourceExt) .generated_inputs -}}
+{{if or $extraFiles $optionalSources .generated_inputs}}
+EXTRA_DIST ={{template "Multiline" $extraFiles -}}
+{{template "Multiline" $optionalSources -}}
+{{template "Multiline" .generated_inputs}}
+{{end}}
+AM_CPPFLAGS = -D{{VarNameUC .name}}_BUILDING
+
+# List the symbols exported by the shared library.
+list-symbols: lib{{.name}}.la
+	@if test -f .libs/lib{{.name}}.so; then \
+		$(NM) -D --defined-only .libs/lib{{.name}}.so | \
+			$(AWK) 'NF == 3 && $$2 ~ /^[BDRTVWiu]$$/ {print $$3}' | \
+			LC_ALL=C sort -u; \
+	else \
+		echo "lib{{.name}}: shared library has not been built" >&2; \
+		exit 1; \
+	fi
+{{if $hasSymbolsFile}}
+# Make sure that every exported symbol is in symbols.txt. Static
+# archives do not reflect symbol visibility, so the check is skipped
+# unless the package is configured with --enable-shared.
+check-symbols: lib{{.name}}.la
+	@if test ! -f .libs/lib{{.name}}.so; then \
+		echo "SKIP: check-symbols: shared library lib{{.name}}" \
+			"has not been built (configure with --enable-shared)"; \
+	else \
+		$(MAKE) -s list-symbols > exported-symbols.txt && \
+		LC_ALL=C sort -u $(top_srcdir)/symbols.txt \
+			> allowed-symbols.txt && \
+		unexpected=` + "`" + `LC_ALL=C comm -23 exported-symbols.txt \
+			allowed-symbols.txt` + "`" + ` && \
+		if test -n "$$unexpected"; then \
+			echo "Symbols missing from symbols.txt:" >&2; \
+			echo "$$unexpected" >&2; \
+			exit 1; \
+		fi; \
+	fi
+
+check-local: check-symbols
+
+MOSTLYCLEANFILES = exported-symbols.txt allowed-symbols.txt
+{{end}}
+.PHONY: list-symbols{{if $hasSymbolsFile}} check-symbols{{end}}
+{{template "SnippetBottom" .}}`)},
 	{"tests/Makefile.am", 0644,
 		[]byte(`{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
 LDADD = ../src/lib$(PACKAGE).la
 
 {{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" -}}
@@ -76,32 +139,97 @@ check_PROGRAMS ={{range $testSources}} \
 
 {{end -}}
 TESTS = $(check_PROGRAMS)
+{{if eq .test_driver "tap"}}
+# Test programs report their results using the
+# Test Anything Protocol.
+LOG_DRIVER = env AM_TAP_AWK='$(AWK)' $(SHELL) \
+	$(top_srcdir)/config/tap-driver.sh
+{{end -}}
 {{$extraFiles := Exclude $allFiles $sourceExt -}}
 {{if $extraFiles}}
 EXTRA_DIST ={{template "Multiline" $extraFiles}}
-{{end -}}
-{{template "Snippet" .}}`)},
+{{end}}
+{{$headerExt := StringList "*?.H" "*?.h" "*?.hh" "*?.hxx" "*?.hpp" -}}
+{{if .headers}}{{$headerExt = .headers}}{{end -}}
+{{$pkgName := .name -}}
+{{$headers := Select (Dir (print "include/" .name)) $headerExt -}}
+{{$generatedHeaders := Exclude (StringList "export.h" "version.h") $headers -}}
+# Make sure that each public header compiles when
+# it is included on its own.
+check_LTLIBRARIES = libheadertest.la
+
+nodist_libheadertest_la_SOURCES =
+{{- range $headers}} \
+	{{template "HeaderTestSource" (print $pkgName "/" .)}}{{end}}
+{{- range $generatedHeaders}} \
+	{{template "HeaderTestSource" (print $pkgName "/" .)}}{{end}}
+
+CLEANFILES = $(nodist_libheadertest_la_SOURCES)
+{{range $headers}}
+{{template "HeaderTestRule" (print $pkgName "/" .)}}{{end}}
+{{- range $generatedHeaders}}
+{{template "HeaderTestRule" (print $pkgName "/" .)}}{{end}}
+{{- template "SnippetBottom" .}}`)},
 	{"Makefile.am", 0644,
 		[]byte(`{{template "FileHeader" . -}}
-{{if gt (len (Dir "m4")) 0 -}}
+{{template "SnippetTop" . -}}
 ACLOCAL_AMFLAGS = -I m4
 
-{{end -}}
 AUTOMAKE_OPTIONS = foreign
 
-SUBDIRS = . include src tests
+{{$hasSymbolsFile := Exists "symbols.txt" -}}
+SUBDIRS = . include src tests{{range .extra_subdirs}} {{.}}{{end}}
 
 pkgconfig_DATA = {{.name}}.pc
 
-EXTRA_DIST = autogen.sh
-{{template "Snippet" .}}`)},
+# CMake package configuration files.
+cmakeconfigdir = $(libdir)/cmake/{{.name}}
+
+cmakeconfig_DATA = {{.name}}Config.cmake {{.name}}ConfigVersion.cmake
+
+# Installation directories are substituted at build time
+# so that they are fully expanded.
+{{.name}}Config.cmake: $(srcdir)/{{.name}}Config.cmake.in Makefile
+	sed -e 's|@libdir[@]|$(libdir)|g' \
+		-e 's|@includedir[@]|$(includedir)|g' \
+		< $(srcdir)/{{.name}}Config.cmake.in > $@
+
+CLEANFILES = {{.name}}Config.cmake
+
+EXTRA_DIST = autogen.sh{{if $hasSymbolsFile}} symbols.txt{{end}} \
+	{{.name}}Config.cmake.in
+
+{{if .bench_dir -}}
+bench: all
+	cd bench && $(MAKE) $(AM_MAKEFLAGS) bench
+
+.PHONY: bench
+
+{{end -}}
+{{template "CoverageTarget" .}}
+# Tag files of the dependencies in the 'file=location' format.
+DOXYGEN_TAGFILES =
+
+if HAVE_DOXYGEN
+docs: Doxyfile
+	DOXYGEN_TAGFILES='$(DOXYGEN_TAGFILES)' $(DOXYGEN) Doxyfile
+else
+docs:
+	@echo "Doxygen was not found at configure time" >&2; exit 1
+endif
+
+clean-local: clean-coverage clean-docs
+
+clean-docs:
+	-rm -rf docs
+
+.PHONY: docs clean-docs
+{{template "SnippetBottom" .}}`)},
 	{"configure.ac", 0644,
 		[]byte(`{{template "FileHeader" . -}}
 AC_INIT([{{.name}}], [{{.version}}])
 AC_CONFIG_AUX_DIR([config])
-{{if gt (len (Dir "m4")) 0 -}}
 AC_CONFIG_MACRO_DIRS([m4])
-{{end -}}
 {{$sources := Dir "src" -}}
 {{if eq (len $sources) 0}}
 {{Error "'lib' template requires at least one source file in src/"}}
@@ -110,8 +238,8 @@ AC_CONFIG_SRCDIR([src/{{index $sources 0}}])
 AC_CONFIG_HEADERS([config.h])
 AM_INIT_AUTOMAKE([foreign])
 
-{{if index . "version-info" -}}
-library_version_info={{index . "version-info"}}
+{{if .version_info -}}
+library_version_info={{.version_info}}
 AC_SUBST(library_version_info)
 
 {{end -}}
@@ -119,39 +247,34 @@ test -z "$CXXFLAGS" && CXXFLAGS=""
 
 AC_PROG_CXX
 LT_INIT([disable-shared])
-PKG_PROG_PKG_CONFIG
+{{if eq .test_driver "tap" -}}
+AC_PROG_AWK
+AC_REQUIRE_AUX_FILE([tap-driver.sh])
+{{end -}}
+{{template "GrammarTools" .}}PKG_PROG_PKG_CONFIG
 PKG_INSTALLDIR
 
 CPPFLAGS="$CPPFLAGS -I\$(top_srcdir)/include -I\$(top_builddir)/include"
 
-dnl When compiling with GNU C++, display more warnings.
-AS_IF([test "$GXX" = yes],
-	[CXXFLAGS="$CXXFLAGS -ansi -pedantic -Wall \
--Woverloaded-virtual -Wsign-promo -W -Wshadow -Wpointer-arith -Wcast-qual \
--Wwrite-strings -Wconversion -Wsign-compare -Wredundant-decls -Winline"],
-dnl Display all levels of the Digital (Compaq) C++ warnings.
-[test "$CXX" = cxx &&
-	cxx -V < /dev/null 2>&1 | grep -Eiq 'digital|compaq'],
-	[DIGITALCXX="yes"
-	CXXFLAGS="$CXXFLAGS -w0 -msg_display_tag -std strict_ansi"],
-dnl Enable all warnings and remarks of the Intel C++ compiler.
-[test "$CXX" = icpc && icpc -V < /dev/null 2>&1 | grep -iq intel],
-	[CXXFLAGS="$CXXFLAGS -w2"])
-
-AC_ARG_ENABLE(debug, AS_HELP_STRING([--enable-debug],
-	[enable debug info and runtime checks (default=no)]))
-
-AM_CONDITIONAL(DEBUG, [test "$enable_debug" = yes])
-
-AS_IF([test "$enable_debug" != yes],
-	[CXXFLAGS="$CXXFLAGS -O3"],
-[CPPFLAGS="$CPPFLAGS -D{{VarNameUC .name}}_DEBUG"
+{{template "CXXFlags" . -}}
+{{template "ConfigureOptions" . -}}
+{{if eq .visibility "hidden"}}
+dnl Hide all symbols that are not explicitly
+dnl exported with {{VarNameUC .name}}_API.
 AS_IF([test "$GXX" = yes],
-	[CXXFLAGS="$CXXFLAGS -ggdb"],
-[test "$DIGITALCXX" = yes],
-	[CXXFLAGS="$CXXFLAGS -gall"],
-[test "$ac_cv_prog_cxx_g" = yes],
-	[CXXFLAGS="$CXXFLAGS -g"])])
+	[AC_LANG_PUSH([C++])
+	AC_MSG_CHECKING([whether $CXX supports -fvisibility=hidden])
+	visibility_save_CXXFLAGS="$CXXFLAGS"
+	CXXFLAGS="$CXXFLAGS -fvisibility=hidden -Werror"
+	AC_COMPILE_IFELSE([AC_LANG_PROGRAM()],
+		[have_visibility=yes], [have_visibility=no])
+	CXXFLAGS="$visibility_save_CXXFLAGS"
+	AC_MSG_RESULT([$have_visibility])
+	AC_LANG_POP([C++])])
+
+AS_IF([test "$have_visibility" = yes],
+	[CXXFLAGS="$CXXFLAGS -fvisibility=hidden -fvisibility-inlines-hidden"])
+{{end -}}
 {{if or .external_libs .requires}}
 dnl Checks for libraries.{{end}}{{if .external_libs}}{{range .external_libs}}
 AC_CHECK_LIB([{{.name}}], [{{.function}}],,
@@ -163,36 +286,115 @@ PKG_PROG_PKG_CONFIG()
 PKG_CHECK_MODULES([{{VarNameUC .}}], [{{VarName .}}])
 CXXFLAGS="$CXXFLAGS ${{VarNameUC .}}_CFLAGS"
 LIBS="$LIBS ${{VarNameUC .}}_LIBS"
-{{end}}{{end -}}
-{{template "Snippet" .}}
-AC_SUBST(CONFIG_FLAGS)
-AC_SUBST(CONFIG_LIBS)
-AC_SUBST(PRIVATE_CONFIG_LIBS)
-AC_SUBST(UNINST_PREFIX)
-AC_SUBST(UNINST_FLAGS)
-AC_SUBST(UNINST_LIBS)
+{{end}}{{end}}
+AC_CHECK_PROG([DOXYGEN], [doxygen], [doxygen])
+AM_CONDITIONAL([HAVE_DOXYGEN], [test -n "$DOXYGEN"])
+{{template "SnippetChecks" .}}
 
 AC_CONFIG_FILES([Makefile
 include/Makefile
 include/{{.name}}/Makefile
 src/Makefile
-tests/Makefile
+tests/Makefile{{range .extra_subdirs}}
+{{.}}/Makefile{{end}}
 {{.name}}.pc
-{{.name}}-uninstalled.pc])
+{{.name}}-uninstalled.pc
+{{.name}}ConfigVersion.cmake
+Doxyfile])
+{{template "SnippetOutput" . -}}
 AC_OUTPUT
+`)},
+	{"include/{name}/version.h", 0644,
+		[]byte(`{{template "VersionHeader" .}}`)},
+	{"include/{name}/export.h", 0644,
+		[]byte(`{{template "ExportHeader" .}}`)},
+	{"Doxyfile.in", 0644,
+		[]byte(`PROJECT_NAME = "@PACKAGE_NAME@"
+PROJECT_NUMBER = @PACKAGE_VERSION@
+PROJECT_BRIEF = {{DoxygenString .description}}
+OUTPUT_DIRECTORY = docs
+INPUT = @top_srcdir@/include/{{.name}}
+RECURSIVE = YES
+STRIP_FROM_PATH = @top_srcdir@/include
+FULL_PATH_NAMES = YES
+ENABLE_PREPROCESSING = YES
+MACRO_EXPANSION = YES
+EXPAND_ONLY_PREDEF = YES
+PREDEFINED = {{VarNameUC .name}}_API= {{VarNameUC .name}}_LOCAL=
+GENERATE_HTML = YES
+GENERATE_LATEX = NO
+GENERATE_TAGFILE = docs/{{.name}}.tag
+TAGFILES = $(DOXYGEN_TAGFILES)
+QUIET = YES
+WARN_IF_UNDOCUMENTED = NO
+`)},
+	{"{name}Config.cmake.in", 0644,
+		[]byte(`{{$target := print .name "::" .name -}}
+{{$var := VarNameUC .name -}}
+# CMake package configuration file for {{.name}}.
+# Provides the {{$target}} imported target.
+{{with .requires}}
+include(CMakeFindDependencyMacro)
+{{range .}}
+find_dependency({{.}})
+{{- end}}
+{{end}}
+if(NOT TARGET {{$target}})
+	find_library({{$var}}_LIBRARY NAMES {{.name}}
+		PATHS "@libdir@" NO_DEFAULT_PATH)
+
+	if(NOT {{$var}}_LIBRARY)
+		set({{.name}}_FOUND FALSE)
+		set({{.name}}_NOT_FOUND_MESSAGE
+			"lib{{.name}} was not found in @libdir@")
+		return()
+	endif()
+
+	add_library({{$target}} UNKNOWN IMPORTED)
+
+	set_target_properties({{$target}} PROPERTIES
+		IMPORTED_LOCATION "${ {{- $var}}_LIBRARY}"
+		INTERFACE_INCLUDE_DIRECTORIES "@includedir@"
+		{{- if or .requires .external_libs}}
+		INTERFACE_LINK_LIBRARIES "
+		{{- range $i, $dep := .public_requires}}{{if $i}};{{end}}{{$dep}}::{{$dep}}{{end}}
+		{{- range $i, $dep := .private_requires}}{{if or $i $.public_requires}};{{end}}$<LINK_ONLY:{{$dep}}::{{$dep}}>{{end}}
+		{{- range $i, $lib := .external_libs}}{{if or $i $.requires}};{{end}}$<LINK_ONLY:{{$lib.name}}>{{end}}"
+		{{- end}})
+endif()
+`)},
+	{"{name}ConfigVersion.cmake.in", 0644,
+		[]byte(`# Version file for the {{.name}} CMake package. A version
+# is compatible with the requested one if it is not older
+# and has the same major version number.
+set(PACKAGE_VERSION "@PACKAGE_VERSION@")
+
+if(PACKAGE_VERSION VERSION_LESS PACKAGE_FIND_VERSION)
+	set(PACKAGE_VERSION_COMPATIBLE FALSE)
+else()
+	string(REGEX MATCH "^[0-9]+" installed_major "${PACKAGE_VERSION}")
+
+	if(PACKAGE_FIND_VERSION_MAJOR STREQUAL installed_major)
+		set(PACKAGE_VERSION_COMPATIBLE TRUE)
+	else()
+		set(PACKAGE_VERSION_COMPATIBLE FALSE)
+	endif()
+
+	if(PACKAGE_FIND_VERSION STREQUAL PACKAGE_VERSION)
+		set(PACKAGE_VERSION_EXACT TRUE)
+	endif()
+endif()
 `)},
 	{"{name}-uninstalled.pc.in", 0644,
-		[]byte(`prefix=@UNINST_PREFIX@
-exec_prefix=@UNINST_PREFIX@
-libdir=@UNINST_PREFIX@/src
-includedir=@UNINST_PREFIX@/include
-
-Name: @PACKAGE_NAME@
-Description: {{.description}}
-Version: @PACKAGE_VERSION@
-Libs: @UNINST_LIBS@
-Libs.private: @PRIVATE_CONFIG_LIBS@
-Cflags: @UNINST_FLAGS@
+		[]byte(`# Linking through the libtool archive keeps the dependency
+# libraries and the run-time search path of the uninstalled library.
+libdir=@abs_top_builddir@/src
+includedir=@abs_top_srcdir@/include
+
+{{template "PkgConfigBody" . -}}
+Libs: ${libdir}/lib{{.name}}.la
+{{template "PkgConfigLibsPrivate" . -}}
+Cflags: -I${includedir} -I@abs_top_builddir@/include
 `)},
 	{"{name}.pc.in", 0644,
 		[]byte(`prefix=@prefix@
@@ -200,11 +402,19 @@ exec_prefix=@exec_prefix@
 libdir=@libdir@
 includedir=@includedir@
 
-Name: @PACKAGE_NAME@
-Description: {{.description}}
-Version: @PACKAGE_VERSION@
-Libs: @CONFIG_LIBS@
-Libs.private: @PRIVATE_CONFIG_LIBS@
-Cflags: @CONFIG_FLAGS@
+{{template "PkgConfigBody" . -}}
+Libs: -L${libdir} -l{{.name}}
+{{template "PkgConfigLibsPrivate" . -}}
+Cflags: -I${includedir}
 `)},
+	{"{man_dir}/Makefile.am", 0644,
+		[]byte(`{{template "ManMakefile" .}}`)},
+	{"{data_dir}/Makefile.am", 0644,
+		[]byte(`{{template "DataMakefile" .}}`)},
+	{"{doc_dir}/Makefile.am", 0644,
+		[]byte(`{{template "DocMakefile" .}}`)},
+	{"{examples_dir}/Makefile.am", 0644,
+		[]byte(`{{template "ExamplesMakefile" .}}`)},
+	{"{bench_dir}/Makefile.am", 0644,
+		[]byte(`{{template "BenchMakefile" .}}`)},
 }
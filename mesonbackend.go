@@ -0,0 +1,248 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+)
+
+// mesonBackend generates Meson projects. Each package is set up
+// in its own build directory; dependencies between the selected
+// packages are resolved through the uninstalled pkg-config files
+// that Meson generates for libraries.
+type mesonBackend struct{}
+
+func (mesonBackend) packageGeneratorFunc(pd *packageDefinition,
+	packageDir string, pi *packageIndex) (func() (bool, error), error) {
+	var t []embeddedTemplateFile
+
+	switch pd.packageType {
+	case "app", "application":
+		t = mesonAppTemplate
+	case "lib", "library":
+		t = mesonLibTemplate
+	case "module", "plugin":
+		t = mesonModuleTemplate
+	default:
+		return nil, errors.New(pd.PackageName +
+			": unknown package type '" + pd.packageType + "'")
+	}
+
+	return func() (bool, error) {
+		return generateBuildFilesFromEmbeddedTemplate(t,
+			mesonDefinitions, packageDir, pd, pi)
+	}, nil
+}
+
+func (b mesonBackend) generateAndBootstrapPackages(ws *workspace,
+	pi *packageIndex, selection packageDefinitionList,
+	conftab *Conftab) error {
+	if _, err := generatePackageFiles(b, ws, pi, selection); err != nil {
+		return err
+	}
+
+	addPackageOptionsToConftab(conftab, selection)
+
+	return generateWorkspaceFiles(ws, b, pi, selection, conftab)
+}
+
+func (mesonBackend) makefileTargets(ws *workspace,
+	selection packageDefinitionList, pi *packageIndex) []target {
+	mtc := newMakefileTargetCollector(ws, selection, pi)
+
+	mtc.addMesonHelpTarget()
+	mtc.addMesonSetupTargets()
+	mtc.addMesonTargets("build", "", "compile")
+	mtc.addMesonTargets("check", "check_", "test")
+	mtc.addMesonTargets("install", "install_", "install")
+
+	return mtc.targets
+}
+
+func (mtc *makefileTargetCollector) buildNinjaFor(
+	pd *packageDefinition) string {
+	return path.Join(mtc.relBuildDir, pd.PackageName, "build.ninja")
+}
+
+func (mtc *makefileTargetCollector) addMesonHelpTarget() {
+	mtc.addTarget("help", true, nil,
+		`	@echo "Usage:"
+	@echo "    make [target...]"
+	@echo
+	@echo "Global targets:"
+	@echo "    help"
+	@echo "        Display this help message. Unless overridden by the"
+	@echo "        '--`+maketargetOption+
+			`' option, this is the default target."
+	@echo
+	@echo "    build"
+	@echo "        Build (compile and link) the selected packages. The"
+	@echo "        packages that have not been set up are set up with"
+	@echo "        'meson setup' automatically."
+	@echo
+	@echo "    check"
+	@echo "        Build and run unit tests for the selected packages."
+	@echo
+	@echo "    install"
+	@echo "        Install package binaries and library headers into"
+	@echo "        '`+mtc.ws.installDir()+`'."
+	@echo
+`)
+}
+
+func (mtc *makefileTargetCollector) addMesonSetupTargets() {
+	cmd := "\t@" + selfPathnameRelativeToWorkspace(mtc.ws) + " configure "
+
+	for _, pd := range mtc.selection {
+		dependencies := []string{
+			path.Join(mtc.pkgRootDir, pd.PackageName, "meson.build")}
+
+		for _, dep := range mtc.selectedDeps[pd] {
+			dependencies = append(dependencies,
+				mtc.buildNinjaFor(dep))
+		}
+
+		mtc.addTarget(mtc.buildNinjaFor(pd), false,
+			dependencies, cmd+pd.PackageName+"\n")
+	}
+}
+
+// addMesonTargets adds the global target 'targetName' and
+// the per-package targets that run the specified meson
+// subcommand in the build directories of the packages.
+func (mtc *makefileTargetCollector) addMesonTargets(targetName,
+	pkgTargetPrefix, mesonCmd string) {
+	var globalDeps []string
+
+	if targetName == "check" {
+		for _, pd := range mtc.selection {
+			globalDeps = append(globalDeps,
+				pkgTargetPrefix+pd.PackageName)
+		}
+	} else {
+		for _, dep := range mtc.globalTargetDeps {
+			globalDeps = append(globalDeps, pkgTargetPrefix+dep)
+		}
+	}
+
+	mtc.addTarget(targetName, true, globalDeps, "")
+
+	logFile := "meson_" + mesonCmd + ".log"
+
+	scriptTemplate := `	@echo '[` + targetName + `] %[1]s'
+	@cd '` + mtc.relBuildDir + `/%[1]s' && \
+	echo '--------------------------------' >> ` + logFile + ` && \
+	date >> ` + logFile + ` && \
+	echo '--------------------------------' >> ` + logFile + ` && \
+	meson ` + mesonCmd
+
+	if targetName == "check" {
+		scriptTemplate += "\n"
+	} else {
+		scriptTemplate += " >> " + logFile + "\n"
+	}
+
+	for _, pd := range mtc.selection {
+		dependencies := []string{mtc.buildNinjaFor(pd)}
+
+		for _, dep := range mtc.selectedDeps[pd] {
+			dependencies = append(dependencies,
+				pkgTargetPrefix+dep.PackageName)
+		}
+
+		mtc.addTarget(pkgTargetPrefix+pd.PackageName, true,
+			dependencies, fmt.Sprintf(scriptTemplate,
+				pd.PackageName))
+	}
+}
+
+func (mesonBackend) configurePackages(ws *workspace, pi *packageIndex,
+	selection packageDefinitionList, conftab *Conftab) error {
+	buildDir := ws.buildDir()
+
+	cfgEnv := prepareConfigureEnv(buildDir)
+
+	// Dependent packages find the libraries built in this
+	// workspace through their uninstalled pkg-config files.
+	addUninstalledDir := func(pkgName string) {
+		cfgEnv.pkgBuildDir[pkgName] = path.Join(buildDir,
+			pkgName, "meson-uninstalled")
+	}
+
+	if configuredPackageDirs, err := ioutil.ReadDir(buildDir); err == nil {
+		for _, dir := range configuredPackageDirs {
+			addUninstalledDir(dir.Name())
+		}
+	}
+
+	for _, pd := range selection {
+		addUninstalledDir(pd.PackageName)
+	}
+
+	installDir := ws.installDir()
+	pkgRootDir := ws.generatedPkgRootDir()
+
+	for _, pd := range selection {
+		if !flags.quiet {
+			fmt.Println("[configure] " + pd.PackageName)
+		}
+
+		pkgBuildDir := path.Join(buildDir, pd.PackageName)
+
+		setupArgs := []string{"setup", "--prefix=" + installDir}
+
+		// Options of the package are set from the conftab.
+		for _, opt := range packageOptions(pd) {
+			value := "false"
+			if opt.isOn(conftab, pd.PackageName) {
+				value = "true"
+			}
+			setupArgs = append(setupArgs, "-D"+opt.Name+"="+value)
+		}
+
+		// Meson refuses to set up a build
+		// directory that has already been set up.
+		_, err := os.Stat(path.Join(pkgBuildDir,
+			"meson-private", "coredata.dat"))
+		if err == nil {
+			setupArgs = append(setupArgs, "--reconfigure")
+		}
+
+		setupArgs = append(setupArgs, pkgBuildDir,
+			path.Join(pkgRootDir, pd.PackageName))
+
+		setupCmd := exec.Command("meson", setupArgs...)
+		setupCmd.Stdout = os.Stdout
+		setupCmd.Stderr = os.Stderr
+		setupCmd.Env = cfgEnv.makeEnv(pd)
+		if err := setupCmd.Run(); err != nil {
+			return errors.New(pd.PackageName + ": meson setup: " +
+				err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (mesonBackend) compileCommands(ws *workspace,
+	selection packageDefinitionList) ([]compileCommand, error) {
+	var commands []compileCommand
+
+	for _, pd := range selection {
+		pkgCommands, err := readCompileCommands(path.Join(
+			ws.buildDir(), pd.PackageName, compdbFilename))
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, pkgCommands...)
+	}
+
+	return commands, nil
+}
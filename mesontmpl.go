@@ -0,0 +1,225 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+// mesonDefinitions extends the common definitions
+// with the fragments of the Meson templates.
+var mesonDefinitions = withCommonDefinitions(map[string]string{
+	"MesonProject": `{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+project({{MesonString .name}}, 'c', 'cpp',
+	version: {{MesonString .version}},
+	meson_version: '>= 0.54.0'
+{{- if .cxx_std}},
+	default_options: ['cpp_std=c++{{.cxx_std}}']{{end}})
+
+cxx = meson.get_compiler('cpp')
+{{if .warning_flags}}
+add_project_arguments(cxx.get_supported_arguments(
+	{{MesonString .warning_flags}}.split()), language: ['c', 'cpp'])
+{{end -}}
+{{range .options}}{{if .Define}}
+if get_option({{MesonString .Name}})
+	add_project_arguments('-D{{.Define}}=1', language: ['c', 'cpp'])
+endif
+{{end}}{{end -}}
+`,
+	"MesonExternalLibs": `
+ext_deps = []
+link_args = []
+{{- range .external_libs}}
+ext_deps += cxx.find_library({{MesonString .name}})
+{{- with .other_libs}}
+link_args += {{MesonString .}}.split(){{end}}{{end}}`,
+	"MesonSourceList": `{{range .}}
+	'src/{{.}}',{{end}}`,
+	"MesonSources": `{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" -}}
+{{if .sources}}{{$sourceExt = .sources}}{{end -}}
+{{$allSources := Select (Dir "src") $sourceExt -}}
+{{if eq (len $allSources) 0}}
+{{Error (print "'" .type "' template requires at least one source file in src/")}}
+{{end -}}
+{{$optionalSources := OptionalSources $allSources .options -}}
+sources = files({{template "MesonSourceList" Exclude $allSources .optional_source_patterns}}
+)
+{{range .options}}{{$option := .Name -}}
+{{with Select $optionalSources .Sources}}
+if get_option({{MesonString $option}})
+	sources += files({{range $i, $source := .}}{{if $i}}, {{end}}'src/{{$source}}'{{end}})
+endif
+{{end}}{{end -}}
+{{range .generated_sources}}
+sources += custom_target({{MesonString (VarName .FirstOutput)}},
+	input: files({{range $i, $input := .Inputs}}{{if $i}}, {{end}}'src/{{$input}}'{{end}}),
+	output: [{{range $i, $output := .Outputs}}{{if $i}}, {{end}}{{MesonString $output}}{{end}}],
+	command: ['sh', '-c', {{MesonString .Command}}])
+{{end -}}
+`,
+})
+
+var mesonOptionsTemplate = embeddedTemplateFile{"meson_options.txt", 0644,
+	[]byte(`{{template "FileHeader" . -}}
+{{range .options -}}
+option({{MesonString .Name}}, type: 'boolean', value: {{if .Default}}true{{else}}false{{end}},
+	description: {{MesonString .Help}})
+{{end}}`)}
+
+var mesonAppTemplate = []embeddedTemplateFile{
+	{"meson.build", 0644,
+		[]byte(`{{template "MesonProject" .}}
+deps = []
+{{- range .requires}}
+deps += dependency({{MesonString .}})
+{{- end}}
+{{template "MesonExternalLibs" .}}
+
+src_inc = include_directories({{if .generated_sources}}'.', {{end}}'src')
+{{if .target_dir}}
+{{range .target_dir}}subdir('src/{{.}}')
+{{end -}}
+{{else}}
+{{template "MesonSources" .}}
+executable({{MesonString .name}}, sources,
+	include_directories: src_inc,
+	link_args: link_args,
+	dependencies: deps + ext_deps,
+	install: true)
+{{end -}}
+{{template "SnippetBottom" .}}`)},
+	mesonOptionsTemplate,
+	{"src/version.h", 0644,
+		[]byte(`{{template "VersionHeader" .}}`)},
+	{"src/{target_dir}/meson.build", 0644,
+		[]byte(`{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+{{$target := index .target_by_dir .target_dir -}}
+{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" -}}
+{{$patterns := $sourceExt -}}
+{{if $target.Sources}}{{$patterns = $target.Sources}}{{end -}}
+{{$sources := Select (Dir .dirname) $patterns -}}
+{{if eq (len $sources) 0}}
+{{Error (print "no source files found for '" $target.Name "' in " .dirname)}}
+{{end -}}
+{{$linkWith := "" -}}
+{{$linkArgs := "link_args" -}}
+{{range $target.LinkNames -}}
+{{if index $.library_by_name . -}}
+{{if $linkWith}}{{$linkWith = print $linkWith ", "}}{{end -}}
+{{$linkWith = print $linkWith (VarName .) "_lib" -}}
+{{else -}}
+{{$linkArgs = print $linkArgs " + [" (MesonString .) "]" -}}
+{{end -}}
+{{end -}}
+{{VarName $target.Name}}_{{if $target.IsProgram}}exe = executable(
+{{- else if $target.Install}}lib = library(
+{{- else}}lib = static_library({{end}}{{MesonString $target.Name}},
+{{- range $sources}}
+	{{MesonString .}},{{end}}
+	include_directories: src_inc,
+{{- if $linkWith}}
+	link_with: [{{$linkWith}}],{{end}}
+	link_args: {{$linkArgs}},
+	dependencies: deps + ext_deps,
+	install: {{if $target.Install}}true{{else}}false{{end}})
+{{template "SnippetBottom" .}}`)},
+}
+
+var mesonLibTemplate = []embeddedTemplateFile{
+	{"meson.build", 0644,
+		[]byte(`{{template "MesonProject" .}}
+public_deps = []
+private_deps = []
+{{- range .public_requires}}
+public_deps += dependency({{MesonString .}})
+{{- end}}
+{{- range .private_requires}}
+private_deps += dependency({{MesonString .}})
+{{- end}}
+{{template "MesonExternalLibs" .}}
+
+{{template "MesonSources" .}}
+cpp_args = ['-D{{VarNameUC .name}}_BUILDING']
+if get_option('default_library') != 'static'
+	cpp_args += '-DDLL_EXPORT'
+endif
+
+inc = include_directories('include')
+{{if .version_info}}
+lt_version = {{MesonString .version_info}}.split(':')
+soversion = lt_version[0].to_int() - lt_version[2].to_int()
+{{end}}
+lib = library({{MesonString .name}}, sources,
+	include_directories: [inc, include_directories({{if .generated_sources}}'.', {{end}}'src')],
+	c_args: cpp_args,
+	cpp_args: cpp_args,
+	link_args: link_args,
+	dependencies: public_deps + private_deps + ext_deps,
+	gnu_symbol_visibility: {{if eq .visibility "hidden"}}'inlineshidden'{{else}}'default'{{end}},
+{{- if .version_info}}
+	version: '@0@.@1@.@2@'.format(soversion, lt_version[2], lt_version[1]),
+	soversion: soversion,{{end}}
+	install: true)
+
+{{VarName .name}}_dep = declare_dependency(link_with: lib,
+	include_directories: inc,
+	dependencies: public_deps)
+{{$testSources := Select (Dir "tests") (StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp") -}}
+{{range $testSources}}
+test({{MesonString (TrimExt .)}}, executable({{MesonString (VarName (TrimExt .))}},
+	'tests/{{.}}',
+	dependencies: {{VarName $.name}}_dep))
+{{end}}
+{{$headerExt := StringList "*?.H" "*?.h" "*?.hh" "*?.hxx" "*?.hpp" -}}
+{{if .headers}}{{$headerExt = .headers}}{{end -}}
+{{$includeDir := print "include/" .name -}}
+install_headers({{range Select (Dir $includeDir) $headerExt}}
+	'{{$includeDir}}/{{.}}',{{end}}
+{{- if not (Exists (print $includeDir "/export.h"))}}
+	'{{$includeDir}}/export.h',{{end}}
+{{- if not (Exists (print $includeDir "/version.h"))}}
+	'{{$includeDir}}/version.h',{{end}}
+	subdir: {{MesonString .name}})
+
+# The pkgconfig module also generates the uninstalled
+# version of the .pc file in the 'meson-uninstalled'
+# subdirectory of the build directory. Dependent packages
+# use it when they are built in the same workspace.
+pkgconfig = import('pkgconfig')
+pkgconfig.generate(lib,
+	name: {{MesonString .name}},
+	description: {{MesonString .description}}
+{{- with .public_requires}},
+	requires: [{{range $i, $dep := .}}{{if $i}}, {{end}}{{MesonString $dep}}{{end}}]{{end}}
+{{- with .private_requires}},
+	requires_private: [{{range $i, $dep := .}}{{if $i}}, {{end}}{{MesonString $dep}}{{end}}]{{end}})
+{{template "SnippetBottom" .}}`)},
+	mesonOptionsTemplate,
+	{"include/{name}/version.h", 0644,
+		[]byte(`{{template "VersionHeader" .}}`)},
+	{"include/{name}/export.h", 0644,
+		[]byte(`{{template "ExportHeader" .}}`)},
+}
+
+var mesonModuleTemplate = []embeddedTemplateFile{
+	{"meson.build", 0644,
+		[]byte(`{{template "MesonProject" .}}
+deps = []
+{{- range .requires}}
+deps += dependency({{MesonString .}})
+{{- end}}
+{{template "MesonExternalLibs" .}}
+
+{{template "MesonSources" .}}
+# Loadable modules do not have the 'lib' prefix.
+shared_module({{MesonString .name}}, sources,
+	name_prefix: '',
+	include_directories: include_directories({{if .generated_sources}}'.', {{end}}'src'),
+	link_args: link_args,
+	dependencies: deps + ext_deps,
+	install: true,
+	install_dir: get_option('libdir') / {{MesonString .name}})
+{{template "SnippetBottom" .}}`)},
+	mesonOptionsTemplate,
+}
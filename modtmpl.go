@@ -0,0 +1,98 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+var moduleTemplate = []embeddedTemplateFile{
+	{"configure.ac", 0644,
+		[]byte(`{{template "FileHeader" . -}}
+AC_INIT([{{.name}}], [{{.version}}])
+AC_CONFIG_AUX_DIR([config])
+AC_CONFIG_MACRO_DIRS([m4])
+{{$sources := Dir "src" -}}
+{{if eq (len $sources) 0}}
+{{Error "'module' template requires at least one source file in src/"}}
+{{end -}}
+AC_CONFIG_SRCDIR([src/{{index $sources 0}}])
+AC_CONFIG_HEADERS([config.h])
+AM_INIT_AUTOMAKE([foreign])
+
+test -z "$CXXFLAGS" && CXXFLAGS=""
+
+AC_PROG_CXX
+
+dnl Loadable modules are useless as static archives,
+dnl so shared builds are always turned on regardless
+dnl of the global conftab defaults.
+enable_shared=yes
+LT_INIT([dlopen disable-static])
+{{template "GrammarTools" .}}
+CPPFLAGS="$CPPFLAGS -I\$(top_srcdir)/include"
+
+{{template "CXXFlags" . -}}
+{{template "ConfigureOptions" . -}}
+{{if or .external_libs .requires}}
+dnl Checks for libraries.{{end}}{{if .external_libs}}{{range .external_libs}}
+AC_CHECK_LIB([{{.name}}], [{{.function}}],,
+	AC_MSG_ERROR([unable to link with {{.name}}]){{if .other_libs}},
+	[{{.other_libs}}]{{end}}){{end}}
+{{end}}{{if .requires}}
+PKG_PROG_PKG_CONFIG()
+{{range .requires}}
+PKG_CHECK_MODULES([{{VarNameUC .}}], [{{VarName .}}])
+CXXFLAGS="$CXXFLAGS ${{VarNameUC .}}_CFLAGS"
+LIBS="$LIBS ${{VarNameUC .}}_LIBS"
+{{end}}{{end -}}
+{{template "SnippetChecks" .}}
+AC_CONFIG_FILES([Makefile
+src/Makefile])
+{{template "SnippetOutput" . -}}
+AC_OUTPUT
+`)},
+	{"Makefile.am", 0644,
+		[]byte(`{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+ACLOCAL_AMFLAGS = -I m4
+
+AUTOMAKE_OPTIONS = foreign
+
+SUBDIRS = . src
+
+EXTRA_DIST = autogen.sh
+
+{{template "CoverageTarget" .}}
+clean-local: clean-coverage
+{{template "SnippetBottom" .}}`)},
+	{"src/Makefile.am", 0644,
+		[]byte(`{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+pkglib_LTLIBRARIES = {{.name}}.la
+
+{{VarName .name}}_la_LDFLAGS = -module -avoid-version -shared
+
+{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" "*?.l" "*?.ll" "*?.y" "*?.yy" -}}
+{{if .sources}}{{$sourceExt = .sources}}{{end -}}
+{{$allFiles := Dir .dirname -}}
+{{$sources := Select $allFiles $sourceExt -}}
+{{$optionalSources := OptionalSources $sources .options -}}
+{{$prefix := VarName .name -}}
+{{$prefix}}_la_SOURCES ={{template "Multiline" Exclude $sources .optional_source_patterns}}
+{{range .options}}{{$conditional := .Conditional -}}
+{{with Select $optionalSources .Sources}}
+if {{$conditional}}
+{{$prefix}}_la_SOURCES +={{template "Multiline" .}}
+endif
+{{end}}{{end -}}
+{{with .generated_outputs}}
+nodist_{{$prefix}}_la_SOURCES ={{template "Multiline" .}}
+{{end -}}
+{{template "GeneratedSources" .generated_sources -}}
+{{$extraFiles := Exclude (Exclude $allFiles $sourceExt) .generated_inputs -}}
+{{if or $extraFiles $optionalSources .generated_inputs}}
+EXTRA_DIST ={{template "Multiline" $extraFiles -}}
+{{template "Multiline" $optionalSources -}}
+{{template "Multiline" .generated_inputs}}
+{{end -}}
+{{template "SnippetBottom" .}}`)},
+}
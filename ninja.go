@@ -0,0 +1,109 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ninjaEdge is a build statement of the generated ninja file.
+type ninjaEdge struct {
+	Output  string   // Escaped output pathname or target name
+	Rule    string   // Either "run" or "phony"
+	Inputs  []string // Escaped input pathnames or target names
+	Command string   // Shell command for the "run" rule
+	Pool    string   // Pool that limits concurrency; none if empty
+}
+
+var ninjaPathEscaper = strings.NewReplacer("$", "$$", " ", "$ ", ":", "$:")
+
+var makeLineContinuation = regexp.MustCompile(`\s*\\\n\t+`)
+
+// makeScriptToShellCommand converts a makefile recipe to a single
+// shell command line. Make runs each line of a recipe in a separate
+// shell, so when the recipe consists of multiple lines, every line
+// is run in a subshell to keep the effect of 'cd' local to it.
+// Dollar signs escaped for make are unescaped.
+func makeScriptToShellCommand(script string) string {
+	script = makeLineContinuation.ReplaceAllString(script, " ")
+	script = strings.Replace(script, "$(MAKE)", "make", -1)
+	script = strings.Replace(script, "$$", "$", -1)
+
+	var commands []string
+
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimLeft(line, "\t")
+		line = strings.TrimPrefix(line, "@")
+		if line != "" {
+			commands = append(commands, line)
+		}
+	}
+
+	if len(commands) > 1 {
+		for i, command := range commands {
+			commands[i] = "(" + command + ")"
+		}
+	}
+
+	return strings.Join(commands, " && ")
+}
+
+// ninjaGeneratorEdge returns the build statement that regenerates
+// the ninja file by running the 'refresh' command when either the
+// selection of packages or the conftab changes.
+func ninjaGeneratorEdge(ws *workspace) ninjaEdge {
+	return ninjaEdge{ninjaFilename, "refresh", []string{
+		ninjaPathEscaper.Replace(path.Join(privateDirName,
+			filenameForSelectedPackages)),
+		ninjaPathEscaper.Replace(path.Join(privateDirName,
+			conftabFilename))},
+		strings.Replace(selfPathnameRelativeToWorkspace(ws)+
+			" refresh", "$", "$$", -1), ""}
+}
+
+// ninjaEdges converts the targets of the meta-Makefile to ninja
+// build statements. Targets that generate files, which are the
+// bootstrap and configure steps, are placed in a pool that makes
+// them run one at a time. The 'check' targets use the console pool
+// so that the test output is displayed as soon as it appears.
+func ninjaEdges(targets []target) []ninjaEdge {
+	var edges []ninjaEdge
+
+	for _, t := range targets {
+		edge := ninjaEdge{Output: ninjaPathEscaper.Replace(t.Target)}
+
+		for _, dep := range t.Dependencies {
+			edge.Inputs = append(edge.Inputs,
+				ninjaPathEscaper.Replace(dep))
+		}
+
+		if t.MakeScript == "" {
+			edge.Rule = "phony"
+		} else {
+			edge.Rule = "run"
+			edge.Command = strings.Replace(
+				makeScriptToShellCommand(t.MakeScript),
+				"$", "$$", -1)
+
+			if !t.Phony {
+				edge.Pool = "configure"
+			} else {
+				// Phony targets must run every time.
+				edge.Inputs = append(edge.Inputs, "FORCE")
+
+				if t.Target == "check" ||
+					strings.HasPrefix(t.Target, "check_") {
+					edge.Pool = "console"
+				}
+			}
+		}
+
+		edges = append(edges, edge)
+	}
+
+	return edges
+}
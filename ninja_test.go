@@ -0,0 +1,61 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMakeScriptToShellCommand(t *testing.T) {
+	command := makeScriptToShellCommand("\t@af configure base\n")
+	if command != "af configure base" {
+		t.Error("Unexpected command: " + command)
+	}
+
+	command = makeScriptToShellCommand(`	@echo '[build] base'
+	@cd 'build/base' && \
+	$(MAKE) >> make.log
+	@mkdir -p dist
+`)
+	expected := "(echo '[build] base') && " +
+		"(cd 'build/base' && make >> make.log) && (mkdir -p dist)"
+	if command != expected {
+		t.Error("Unexpected command: " + command)
+	}
+}
+
+func TestNinjaEdges(t *testing.T) {
+	edges := ninjaEdges([]target{
+		{"build/my pkg/Makefile", false, []string{"c:onftab"},
+			"\t@af configure 'my pkg'\n"},
+		{"build", true, []string{"base"}, ""},
+		{"check_base", true, nil, "\t@echo $$HOME\n"},
+	})
+
+	expected := []ninjaEdge{
+		{"build/my$ pkg/Makefile", "run", []string{"c$:onftab"},
+			"af configure 'my pkg'", "configure"},
+		{"build", "phony", []string{"base"}, "", ""},
+		{"check_base", "run", []string{"FORCE"},
+			"echo $$HOME", "console"},
+	}
+
+	if !reflect.DeepEqual(edges, expected) {
+		t.Errorf("Unexpected ninja edges: %v", edges)
+	}
+}
+
+func TestNinjaGeneratorEdge(t *testing.T) {
+	edge := ninjaGeneratorEdge(&workspace{absDir: "/ws"})
+
+	if edge.Output != ninjaFilename || edge.Rule != "refresh" ||
+		!reflect.DeepEqual(edge.Inputs, []string{
+			".autoforge/selected", ".autoforge/conftab"}) ||
+		!strings.HasSuffix(edge.Command, " refresh") {
+		t.Errorf("Unexpected generator edge: %v", edge)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// configureOption describes an entry of the 'options' list in
+// a package definition file, which is an --enable-FEATURE or a
+// --with-PACKAGE option of the generated configure script.
+type configureOption struct {
+	Name        string   // Feature or package name
+	Kind        string   // Either "enable" or "with"
+	Default     bool     // Whether the option is on by default
+	Help        string   // Option description
+	Define      string   // Preprocessor macro; none if empty
+	Conditional string   // Automake conditional
+	Sources     []string // Patterns of sources built with the option on
+}
+
+// ShellVar returns the name of the shell variable
+// that holds the value of the option.
+func (opt *configureOption) ShellVar() string {
+	return opt.Kind + "_" + varName(opt.Name)
+}
+
+// HelpFlag returns the option as it appears in 'configure --help'.
+func (opt *configureOption) HelpFlag() string {
+	switch {
+	case opt.Kind == "enable" && opt.Default:
+		return "--disable-" + opt.Name
+	case opt.Kind == "enable":
+		return "--enable-" + opt.Name
+	case opt.Default:
+		return "--without-" + opt.Name
+	}
+	return "--with-" + opt.Name
+}
+
+// DefaultValue returns the shell value of the option
+// for when it is not given on the command line.
+func (opt *configureOption) DefaultValue() string {
+	if opt.Default {
+		return "yes"
+	}
+	return "no"
+}
+
+// CMakeVar returns the name of the CMake cache variable that holds
+// the value of the option. All packages share the same cache in the
+// superbuild, so the conditional is prefixed with the package name.
+func (opt *configureOption) CMakeVar(pkgName string) string {
+	return varNameUC(pkgName) + "_" + opt.Conditional
+}
+
+// conftabKey returns the key of the option in the conftab.
+func (opt *configureOption) conftabKey() optionKey {
+	if opt.Kind == "with" {
+		return optionKey{optPkg, opt.Name}
+	}
+	return optionKey{optFeat, opt.Name}
+}
+
+// isOn returns whether the option is turned on in the conftab
+// section of the package. The option keeps its default value
+// if the conftab does not define it.
+func (opt *configureOption) isOn(conftab *Conftab, pkgName string) bool {
+	definition := conftab.getOptionDefinition(pkgName, opt.conftabKey())
+	if definition == "" {
+		return opt.Default
+	}
+
+	definition = strings.TrimPrefix(definition, "--")
+	value := ""
+	if eq := strings.IndexByte(definition, '='); eq >= 0 {
+		definition, value = definition[:eq], definition[eq+1:]
+	}
+
+	return (strings.HasPrefix(definition, "enable-") ||
+		strings.HasPrefix(definition, "with-")) && value != "no"
+}
+
+// packageOptions returns the declarative options of the package.
+func packageOptions(pd *packageDefinition) []*configureOption {
+	options, _ := pd.params["options"].([]*configureOption)
+	return options
+}
+
+// addPackageOptionsToConftab adds the declarative options of the
+// selected packages to the conftab for the backends that do not
+// generate configure scripts.
+func addPackageOptionsToConftab(conftab *Conftab,
+	selection packageDefinitionList) {
+	for _, pd := range selection {
+		for _, opt := range packageOptions(pd) {
+			conftab.addOption(pd.PackageName, &optDescription{
+				opt.conftabKey(), opt.Help, opt.HelpFlag()})
+		}
+	}
+}
+
+// parseConfigureOptions replaces the 'options' list in 'params'
+// with a list of parsed configureOption structures. It also sets
+// the 'optional_source_patterns' parameter to the list of patterns
+// of all sources that are built conditionally.
+func parseConfigureOptions(pathname string, params templateParams) error {
+	optionalSourcePatterns := []string{}
+
+	value := params["options"]
+	if value == nil {
+		params["optional_source_patterns"] = optionalSourcePatterns
+		return nil
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return errors.New(pathname + ": 'options' must be a list")
+	}
+
+	var options []*configureOption
+
+	for _, elem := range list {
+		entry, ok := elem.(map[interface{}]interface{})
+		if !ok {
+			return errors.New(pathname +
+				": each entry in 'options' must be a map")
+		}
+
+		entryParams := templateParams{}
+		for key, val := range entry {
+			if keyStr, ok := key.(string); ok {
+				entryParams[keyStr] = val
+			}
+		}
+
+		name, err := getRequiredStringField(pathname,
+			entryParams, "name")
+		if err != nil {
+			return err
+		}
+
+		opt := &configureOption{Name: name, Kind: "enable"}
+
+		if kind, ok := entryParams["kind"].(string); ok {
+			opt.Kind = kind
+		}
+		if opt.Kind != "enable" && opt.Kind != "with" {
+			return errors.New(pathname + ": option '" + name +
+				"': 'kind' must be either 'enable' or 'with'")
+		}
+
+		switch defaultValue := entryParams["default"]; defaultValue {
+		case nil, false, "no":
+		case true, "yes":
+			opt.Default = true
+		default:
+			return errors.New(pathname + ": option '" + name +
+				"': invalid default value '" +
+				fmt.Sprint(defaultValue) + "'")
+		}
+
+		if help, ok := entryParams["help"].(string); ok {
+			opt.Help = help
+		} else {
+			opt.Help = opt.Kind + " " + name
+		}
+
+		if define, ok := entryParams["define"].(string); ok {
+			opt.Define = define
+		}
+
+		if conditional, ok := entryParams["conditional"].(string); ok {
+			opt.Conditional = conditional
+		} else {
+			opt.Conditional = varNameUC(opt.Kind + "_" + name)
+		}
+
+		opt.Sources, err = getStringListField(pathname,
+			entryParams["sources"], "options: sources")
+		if err != nil {
+			return err
+		}
+		if opt.Sources == nil {
+			opt.Sources = []string{"opt-" + name + "/**"}
+		}
+		optionalSourcePatterns = append(optionalSourcePatterns,
+			opt.Sources...)
+
+		options = append(options, opt)
+	}
+
+	params["options"] = options
+	params["optional_source_patterns"] = optionalSourcePatterns
+
+	return nil
+}
+
+// optionalSources returns the sources that are built conditionally,
+// that is, the sources that match the patterns of one of the options.
+// A source that matches the patterns of two options would be compiled
+// and linked twice when both options are on, which is an error.
+func optionalSources(sources []string,
+	options []*configureOption) ([]string, error) {
+	var result []string
+
+	for _, source := range sources {
+		var owner *configureOption
+		for _, opt := range options {
+			if !matchAny(source, opt.Sources) {
+				continue
+			}
+			if owner != nil {
+				return nil, errors.New("source file '" + source +
+					"' matches the patterns of both '" +
+					owner.Name + "' and '" + opt.Name +
+					"' options")
+			}
+			owner = opt
+		}
+		if owner != nil {
+			result = append(result, source)
+		}
+	}
+
+	return result, nil
+}
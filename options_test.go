@@ -0,0 +1,106 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfigureOptions(t *testing.T) {
+	var params templateParams
+
+	if err := yaml.Unmarshal([]byte(`
+options:
+  - name: fast-math
+    define: USE_FAST_MATH
+  - name: zlib
+    kind: with
+    default: yes
+`), &params); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := parseConfigureOptions("test.yaml", params); err != nil {
+		t.Fatal(err)
+	}
+
+	options := params["options"].([]*configureOption)
+
+	if len(options) != 2 {
+		t.Fatal("Unexpected number of options")
+	}
+
+	if options[0].HelpFlag() != "--enable-fast-math" ||
+		options[0].ShellVar() != "enable_fast_math" ||
+		options[0].Conditional != "ENABLE_FAST_MATH" {
+		t.Error("Unexpected definition of the 'enable' option")
+	}
+
+	if options[1].HelpFlag() != "--without-zlib" ||
+		options[1].DefaultValue() != "yes" ||
+		options[1].Conditional != "WITH_ZLIB" ||
+		options[1].CMakeVar("my-pkg") != "MY_PKG_WITH_ZLIB" {
+		t.Error("Unexpected definition of the 'with' option")
+	}
+}
+
+func TestInvalidConfigureOption(t *testing.T) {
+	params := templateParams{"options": []interface{}{
+		map[interface{}]interface{}{"name": "x", "kind": "use"}}}
+
+	if parseConfigureOptions("test.yaml", params) == nil {
+		t.Error("Invalid option kind was accepted")
+	}
+}
+
+func TestOptionalSources(t *testing.T) {
+	options := []*configureOption{
+		{Name: "x", Sources: []string{"x*.cc"}},
+		{Name: "y", Sources: []string{"opt-y/**"}}}
+
+	sources, err := optionalSources([]string{
+		"main.cc", "x1.cc", "opt-y/y.cc"}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 || sources[0] != "x1.cc" ||
+		sources[1] != "opt-y/y.cc" {
+		t.Error("Unexpected optional sources:", sources)
+	}
+
+	if _, err = optionalSources([]string{"opt-y/x2.cc"},
+		options); err == nil {
+		t.Error("Overlapping option sources were accepted")
+	}
+}
+
+func TestOptionStateFromConftab(t *testing.T) {
+	conftab := newConftab()
+
+	fast := &configureOption{Name: "fast", Kind: "enable", Default: true}
+	zlib := &configureOption{Name: "zlib", Kind: "with"}
+	gui := &configureOption{Name: "gui", Kind: "enable"}
+
+	addPackageOptionsToConftab(conftab, packageDefinitionList{
+		&packageDefinition{PackageName: "p", params: templateParams{
+			"options": []*configureOption{fast, zlib, gui}}}})
+
+	// Novel options are commented out and keep their defaults.
+	if !fast.isOn(conftab, "p") || zlib.isOn(conftab, "p") {
+		t.Error("Options without definitions must keep defaults")
+	}
+
+	section := conftab.sectionByPackageName["p"]
+	section.options[fast.conftabKey()] = "--disable-fast"
+	section.options[zlib.conftabKey()] = "--with-zlib=/opt/zlib"
+	conftab.GlobalSection.options[gui.conftabKey()] = "--enable-gui=no"
+
+	if fast.isOn(conftab, "p") || !zlib.isOn(conftab, "p") ||
+		gui.isOn(conftab, "p") {
+		t.Error("Option values were not taken from the conftab")
+	}
+}
@@ -33,6 +33,18 @@ type packageDefinition struct {
 
 type packageDefinitionList []*packageDefinition
 
+// Params returns the parameters from the package definition file.
+// This method makes package parameters accessible from templates.
+func (pd *packageDefinition) Params() templateParams {
+	return pd.params
+}
+
+// isLibrary returns true if the package is built
+// using the built-in library template.
+func (pd *packageDefinition) isLibrary() bool {
+	return pd.packageType == "lib" || pd.packageType == "library"
+}
+
 func getRequiredField(pathname string, params templateParams,
 	fieldName string) (interface{}, error) {
 	if value := params[fieldName]; value != nil {
@@ -55,8 +67,68 @@ func getRequiredStringField(pathname string, params templateParams,
 	}
 }
 
-func loadPackageDefinition(pathname string) (*packageDefinition, []string,
-	error) {
+// normalizeParams validates the optional package parameters that
+// the built-in templates rely on and converts them to the types
+// that the templates expect.
+func normalizeParams(pathname string, params templateParams,
+	quiet bool) error {
+	for _, fieldName := range []string{"sources", "headers"} {
+		list, err := getStringListField(pathname,
+			params[fieldName], fieldName)
+		if err != nil {
+			return err
+		}
+		if list != nil {
+			params[fieldName] = list
+		}
+	}
+
+	if err := normalizeCXXStd(pathname, params); err != nil {
+		return err
+	}
+
+	if err := normalizeWarnings(pathname, params); err != nil {
+		return err
+	}
+
+	if err := normalizeVisibility(pathname, params); err != nil {
+		return err
+	}
+
+	if err := normalizeTestDriver(pathname, params); err != nil {
+		return err
+	}
+
+	if versionInfo := params["version-info"]; versionInfo != nil {
+		if !quiet {
+			log.Printf("%s: 'version-info' is deprecated; "+
+				"use 'version_info' instead\n", pathname)
+		}
+		if params["version_info"] == nil {
+			params["version_info"] = versionInfo
+		}
+		delete(params, "version-info")
+	}
+
+	if err := normalizeSnippets(pathname, params); err != nil {
+		return err
+	}
+
+	if err := parseConfigureOptions(pathname, params); err != nil {
+		return err
+	}
+
+	if err := parseGeneratedSources(pathname, params); err != nil {
+		return err
+	}
+
+	detectConventionalSubdirs(pathname, params)
+
+	return nil
+}
+
+func loadPackageDefinition(pathname string, quiet bool) (*packageDefinition,
+	[]string, error) {
 	data, err := ioutil.ReadFile(pathname)
 	if err != nil {
 		return nil, nil, err
@@ -91,23 +163,17 @@ func loadPackageDefinition(pathname string) (*packageDefinition, []string,
 		return nil, nil, err
 	}
 
-	requires := []string{}
+	requires, err := parseRequires(pathname, params)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if requiredPackages := params["requires"]; requiredPackages != nil {
-		pkgList, ok := requiredPackages.([]interface{})
-		if !ok {
-			return nil, nil, errors.New(pathname +
-				": 'requires' must be a list")
-		}
-		for _, pkgName := range pkgList {
-			pkgNameStr, ok := pkgName.(string)
-			if !ok {
-				return nil, nil, errors.New(pathname +
-					": 'requires' must be " +
-					"a list of strings")
-			}
-			requires = append(requires, pkgNameStr)
-		}
+	if err = normalizeParams(pathname, params, quiet); err != nil {
+		return nil, nil, err
+	}
+
+	if err = parseBuildTargets(pathname, packageType, params); err != nil {
+		return nil, nil, err
 	}
 
 	return &packageDefinition{
@@ -166,7 +232,7 @@ func readPackageDefinitions(wp *workspaceParams) (*packageIndex, error) {
 			}
 
 			pd, requires, err := loadPackageDefinition(
-				dirEntryPathname)
+				dirEntryPathname, wp.Quiet)
 			if err != nil {
 				return nil, err
 			}
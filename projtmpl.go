@@ -235,7 +235,8 @@ func pathnamesNotInDir(pathnameTemplate string, params templateParams,
 // 'projectDir' with the same relative pathname as the respective source
 // file in 'templateDir'.
 func generateBuildFilesFromProjectTemplate(templateDir,
-	projectDir string, pd *packageDefinition) (bool, error) {
+	projectDir string, pd *packageDefinition,
+	pi *packageIndex) (bool, error) {
 
 	dirTree, changesMade, err := linkFilesFromSourceDir(pd, projectDir)
 	if err != nil {
@@ -261,7 +262,8 @@ func generateBuildFilesFromProjectTemplate(templateDir,
 
 		filesUpdated, err := generateFilesFromProjectFileTemplate(
 			projectDir, relativePathname, templateContents,
-			sourceFileInfo.Mode(), pd, dirTree, fileParams)
+			sourceFileInfo.Mode(), commonDefinitions, pd, pi,
+			dirTree, fileParams)
 		if err != nil {
 			return err
 		}
@@ -286,15 +288,18 @@ type embeddedTemplateFile struct {
 
 // generateBuildFilesFromEmbeddedTemplate generates project build
 // files from a built-in template pointed to by the 't' parameter.
+// The 'definitions' map contains the named templates that the
+// template files can refer to.
 func generateBuildFilesFromEmbeddedTemplate(t []embeddedTemplateFile,
-	projectDir string, pd *packageDefinition) (bool, error) {
+	definitions map[string]string, projectDir string,
+	pd *packageDefinition, pi *packageIndex) (bool, error) {
 
 	dirTree, changesMade, err := linkFilesFromSourceDir(pd, projectDir)
 	if err != nil {
 		return false, err
 	}
 
-	for _, fileInfo := range append(t, commonTemplateFiles...) {
+	for _, fileInfo := range t {
 		fileParams := pathnamesNotInDir(fileInfo.pathname,
 			pd.params, dirTree)
 
@@ -304,7 +309,8 @@ func generateBuildFilesFromEmbeddedTemplate(t []embeddedTemplateFile,
 
 		filesUpdated, err := generateFilesFromProjectFileTemplate(
 			projectDir, fileInfo.pathname, fileInfo.contents,
-			fileInfo.mode, pd, dirTree, fileParams)
+			fileInfo.mode, definitions, pd, pi, dirTree,
+			fileParams)
 		if err != nil {
 			return false, err
 		}
@@ -316,19 +322,28 @@ func generateBuildFilesFromEmbeddedTemplate(t []embeddedTemplateFile,
 	return changesMade, nil
 }
 
-func (pd *packageDefinition) getPackageGeneratorFunc(
-	packageDir string) (func() (bool, error), error) {
+func (pd *packageDefinition) getPackageGeneratorFunc(packageDir string,
+	pi *packageIndex) (func() (bool, error), error) {
 	switch pd.packageType {
 	case "app", "application":
 		return func() (bool, error) {
 			return generateBuildFilesFromEmbeddedTemplate(
-				appTemplate, packageDir, pd)
+				append(appTemplate, commonTemplateFiles...),
+				commonDefinitions, packageDir, pd, pi)
 		}, nil
 
 	case "lib", "library":
 		return func() (bool, error) {
 			return generateBuildFilesFromEmbeddedTemplate(
-				libTemplate, packageDir, pd)
+				append(libTemplate, commonTemplateFiles...),
+				commonDefinitions, packageDir, pd, pi)
+		}, nil
+
+	case "module", "plugin":
+		return func() (bool, error) {
+			return generateBuildFilesFromEmbeddedTemplate(
+				append(moduleTemplate, commonTemplateFiles...),
+				commonDefinitions, packageDir, pd, pi)
 		}, nil
 
 	default:
@@ -0,0 +1,135 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// parseRequires parses the 'requires' parameter. Each entry is
+// either a package name, which denotes a public dependency, or a
+// map with the 'name' and 'scope' keys, where the scope is either
+// 'public' or 'private'. Headers of public dependencies can be
+// included by the public headers of the package, whereas private
+// dependencies are only used by the implementation.
+//
+// The 'requires' parameter is replaced with the list of all required
+// package names; the 'public_requires' and 'private_requires'
+// parameters receive the respective subsets of that list.
+func parseRequires(pathname string, params templateParams) ([]string,
+	error) {
+	requires := []string{}
+	publicRequires := []string{}
+	privateRequires := []string{}
+
+	if requiredPackages := params["requires"]; requiredPackages != nil {
+		pkgList, ok := requiredPackages.([]interface{})
+		if !ok {
+			return nil, errors.New(pathname +
+				": 'requires' must be a list")
+		}
+		for _, entry := range pkgList {
+			var pkgName, scope string
+
+			switch value := entry.(type) {
+			case string:
+				pkgName, scope = value, "public"
+			case map[interface{}]interface{}:
+				pkgName, ok = value["name"].(string)
+				if !ok {
+					return nil, errors.New(pathname +
+						": 'requires' entries must " +
+						"have a 'name'")
+				}
+				switch value["scope"] {
+				case nil, "public":
+					scope = "public"
+				case "private":
+					scope = "private"
+				default:
+					return nil, errors.New(pathname +
+						": 'scope' of '" + pkgName +
+						"' must be either " +
+						"'public' or 'private'")
+				}
+			default:
+				return nil, errors.New(pathname +
+					": 'requires' must be a list " +
+					"of package names or maps")
+			}
+
+			requires = append(requires, pkgName)
+			if scope == "public" {
+				publicRequires = append(publicRequires,
+					pkgName)
+			} else {
+				privateRequires = append(privateRequires,
+					pkgName)
+			}
+		}
+	}
+
+	if len(requires) > 0 {
+		params["requires"] = requires
+	}
+	params["public_requires"] = publicRequires
+	params["private_requires"] = privateRequires
+
+	return requires, nil
+}
+
+var includeDirectiveRegexp = regexp.MustCompile(
+	`^\s*#\s*include\s*[<"]([^/>"]+)/`)
+
+// warnAboutPrivateIncludes checks the public headers of a library
+// and logs a warning for each header that includes a header of a
+// private dependency.
+func warnAboutPrivateIncludes(pd *packageDefinition) error {
+	privateRequires, _ := pd.params["private_requires"].([]string)
+	if len(privateRequires) == 0 {
+		return nil
+	}
+
+	isPrivate := map[string]bool{}
+	for _, pkgName := range privateRequires {
+		isPrivate[pkgName] = true
+	}
+
+	includeDir := filepath.Join(filepath.Dir(pd.pathname),
+		"include", pd.PackageName)
+
+	if _, err := os.Stat(includeDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	return processAllFiles(includeDir, func(sourcePathname,
+		relativePathname string, info os.FileInfo) error {
+		f, err := os.Open(sourcePathname)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		lineNumber := 0
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lineNumber++
+			match := includeDirectiveRegexp.FindStringSubmatch(
+				scanner.Text())
+			if match != nil && isPrivate[match[1]] {
+				log.Printf("%s:%d: public header includes "+
+					"a header of the private dependency "+
+					"'%s'\n", sourcePathname, lineNumber,
+					match[1])
+			}
+		}
+		return scanner.Err()
+	})
+}
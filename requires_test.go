@@ -0,0 +1,41 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRequires(t *testing.T) {
+	params := templateParams{"requires": []interface{}{"base",
+		map[interface{}]interface{}{
+			"name": "impl", "scope": "private"},
+		map[interface{}]interface{}{"name": "api"}}}
+
+	requires, err := parseRequires("test.yaml", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(requires, []string{"base", "impl", "api"}) {
+		t.Error("Unexpected list of required packages:", requires)
+	}
+
+	if !reflect.DeepEqual(params["public_requires"],
+		[]string{"base", "api"}) ||
+		!reflect.DeepEqual(params["private_requires"],
+			[]string{"impl"}) {
+		t.Error("Dependencies are not split by scope")
+	}
+
+	params = templateParams{"requires": []interface{}{
+		map[interface{}]interface{}{
+			"name": "base", "scope": "protected"}}}
+
+	if _, err = parseRequires("test.yaml", params); err == nil {
+		t.Error("Invalid scope was accepted")
+	}
+}
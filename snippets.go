@@ -0,0 +1,137 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// snippetDirName is the name of the package subdirectory
+// from which snippets are loaded. The pathname of each file
+// in this directory consists of the name of the generated
+// file and the name of the hook, e.g. 'src/Makefile.am/top'.
+var snippetDirName = "snippets"
+
+// defaultSnippetHook returns the name of the hook that receives
+// snippets that are defined without an explicit hook name.
+func defaultSnippetHook(filename string) string {
+	if path.Base(filename) == "configure.ac" {
+		return "checks"
+	}
+	return "bottom"
+}
+
+// snippetKey returns the key that the built-in templates use to
+// look up the snippet for the specified file and hook. Keys that
+// do not contain a hook name are converted to refer to the default
+// hook of the file.
+func snippetKey(key string) string {
+	if strings.Contains(key, ":") {
+		return key
+	}
+	return key + ":" + defaultSnippetHook(key)
+}
+
+func addSnippet(snippets map[string]string, key, text string) {
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if existing, found := snippets[key]; found {
+		text = existing + "\n" + text
+	}
+	snippets[key] = text
+}
+
+// loadSnippetFiles adds snippets from the files in the 'snippets'
+// subdirectory of the package directory.
+func loadSnippetFiles(snippets map[string]string, packageDir string) error {
+	snippetDir := path.Join(packageDir, snippetDirName)
+
+	if _, err := os.Stat(snippetDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	return processAllFiles(snippetDir, func(sourcePathname,
+		relativePathname string, info os.FileInfo) error {
+		hookPos := strings.LastIndex(relativePathname, "/")
+		if hookPos < 0 {
+			return errors.New(sourcePathname + ": snippet " +
+				"files must be named <file>/<hook>")
+		}
+
+		text, err := ioutil.ReadFile(sourcePathname)
+		if err != nil {
+			return err
+		}
+
+		addSnippet(snippets, relativePathname[:hookPos]+":"+
+			relativePathname[hookPos+1:], string(text))
+
+		return nil
+	})
+}
+
+// normalizeSnippets merges the 'snippets' map from the package
+// definition, the 'configure' shorthand, and the snippet files
+// into a single map from '<file>:<hook>' keys to snippet texts.
+func normalizeSnippets(pathname string, params templateParams) error {
+	snippets := map[string]string{}
+
+	if value := params["snippets"]; value != nil {
+		snippetMap, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return errors.New(pathname +
+				": 'snippets' must be a map")
+		}
+		var keys []string
+		for key := range snippetMap {
+			keyStr, ok := key.(string)
+			if !ok {
+				return errors.New(pathname +
+					": snippet keys must be strings")
+			}
+			keys = append(keys, keyStr)
+		}
+		// Sort the keys so that snippets with and without
+		// explicit hook names are merged in a stable order.
+		sort.Strings(keys)
+
+		for _, keyStr := range keys {
+			textStr, ok := snippetMap[keyStr].(string)
+			if !ok {
+				return errors.New(pathname + ": snippet '" +
+					keyStr + "' must be a string")
+			}
+			addSnippet(snippets, snippetKey(keyStr), textStr)
+		}
+	}
+
+	// The 'configure' parameter is a shorthand
+	// for the configure.ac snippet.
+	if configure := params["configure"]; configure != nil {
+		configureSnippet, ok := configure.(string)
+		if !ok {
+			return errors.New(pathname +
+				": 'configure' must be a string")
+		}
+		addSnippet(snippets, snippetKey("configure.ac"),
+			configureSnippet)
+	}
+
+	if err := loadSnippetFiles(snippets,
+		filepath.Dir(pathname)); err != nil {
+		return err
+	}
+
+	params["snippets"] = snippets
+
+	return nil
+}
@@ -0,0 +1,38 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSnippetNormalization(t *testing.T) {
+	params := templateParams{
+		"snippets": map[interface{}]interface{}{
+			"configure.ac":        "AC_CHECK_FUNCS([a])",
+			"configure.ac:checks": "AC_CHECK_FUNCS([b])\n",
+			"Makefile.am":         "all-local:\n",
+			"src/Makefile.am:top": "FOO = 1\n",
+		},
+		"configure": "AC_CHECK_FUNCS([c])\n",
+	}
+
+	if err := normalizeSnippets("nonexistent/test.yaml",
+		params); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"configure.ac:checks": "AC_CHECK_FUNCS([a])\n\n" +
+			"AC_CHECK_FUNCS([b])\n\nAC_CHECK_FUNCS([c])\n",
+		"Makefile.am:bottom":  "all-local:\n",
+		"src/Makefile.am:top": "FOO = 1\n",
+	}
+
+	if !reflect.DeepEqual(params["snippets"], expected) {
+		t.Error("Unexpected snippets:", params["snippets"])
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// conventionalSubdirs lists the package subdirectories that the
+// built-in templates recognize in addition to 'src', 'include',
+// and 'tests'. The order of this list defines the order in which
+// the subdirectories appear in SUBDIRS.
+var conventionalSubdirs = []string{
+	"man", "data", "doc", "examples", "bench"}
+
+// detectConventionalSubdirs checks which of the conventional
+// subdirectories exist in the package directory. For each of
+// them, a '<dir>_dir' parameter is set to a list that contains
+// the name of the directory if it exists and is empty otherwise,
+// so that the Makefile.am for the directory is generated only
+// when needed. The 'extra_subdirs' parameter receives the list
+// of all subdirectories that were found.
+func detectConventionalSubdirs(pathname string, params templateParams) {
+	packageDir := filepath.Dir(pathname)
+
+	extraSubdirs := []string{}
+
+	for _, dir := range conventionalSubdirs {
+		found := []string{}
+		if fi, err := os.Stat(filepath.Join(packageDir,
+			dir)); err == nil && fi.IsDir() {
+			found = append(found, dir)
+			extraSubdirs = append(extraSubdirs, dir)
+		}
+		params[dir+"_dir"] = found
+	}
+
+	params["extra_subdirs"] = extraSubdirs
+}
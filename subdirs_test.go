@@ -0,0 +1,51 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConventionalSubdirs(t *testing.T) {
+	packageDir, err := ioutil.TempDir("", "subdirs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(packageDir)
+
+	for _, dir := range []string{"examples", "man"} {
+		if err = os.Mkdir(filepath.Join(packageDir, dir),
+			os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A regular file must not be mistaken for a directory.
+	if err = ioutil.WriteFile(filepath.Join(packageDir, "doc"),
+		nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params := templateParams{}
+
+	detectConventionalSubdirs(filepath.Join(packageDir,
+		"autoforge.yaml"), params)
+
+	if !reflect.DeepEqual(params["extra_subdirs"],
+		[]string{"man", "examples"}) {
+		t.Error("Unexpected list of subdirectories:",
+			params["extra_subdirs"])
+	}
+
+	if !reflect.DeepEqual(params["man_dir"], []string{"man"}) ||
+		len(params["doc_dir"].([]string)) != 0 ||
+		len(params["data_dir"].([]string)) != 0 {
+		t.Error("Unexpected subdirectory parameters")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 )
 
 type target struct {
@@ -27,8 +28,9 @@ type makefileTargetCollector struct {
 	targets          []target
 }
 
-func createMakefileTargets(ws *workspace, selection packageDefinitionList,
-	pi *packageIndex) []target {
+func newMakefileTargetCollector(ws *workspace,
+	selection packageDefinitionList,
+	pi *packageIndex) *makefileTargetCollector {
 
 	selectedDeps := establishDependenciesInSelection(selection, pi)
 
@@ -48,16 +50,24 @@ func createMakefileTargets(ws *workspace, selection packageDefinitionList,
 		}
 	}
 
-	mtc := &makefileTargetCollector{ws,
+	return &makefileTargetCollector{ws,
 		ws.buildDirRelativeToWorkspace(),
 		ws.pkgRootDirRelativeToWorkspace(),
 		selection, selectedDeps, globalTargetDeps, nil}
+}
+
+func createMakefileTargets(ws *workspace, selection packageDefinitionList,
+	pi *packageIndex) []target {
+	mtc := newMakefileTargetCollector(ws, selection, pi)
 
 	mtc.addHelpTarget()
 	mtc.addBootstrapTargets()
 	mtc.addConfigureTargets()
 	mtc.addBuildTargets()
 	mtc.addCheckTargets()
+	mtc.addCoverageTargets()
+	mtc.addDocsTargets()
+	mtc.addBenchTargets()
 	mtc.addInstallTargets()
 	mtc.addDistTargets()
 
@@ -108,6 +118,22 @@ func (mtc *makefileTargetCollector) addHelpTarget() {
 	@echo "    check"
 	@echo "        Build and run unit tests for the selected packages."
 	@echo
+	@echo "    coverage"
+	@echo "        Run unit tests of the selected packages and merge"
+	@echo "        their coverage reports into 'coverage.info'. The"
+	@echo "        packages must be configured with --enable-coverage."
+	@echo
+	@echo "    docs"
+	@echo "        Generate API documentation for the selected"
+	@echo "        libraries using Doxygen. Cross-references to"
+	@echo "        the selected dependencies are resolved through"
+	@echo "        Doxygen tag files."
+	@echo
+	@echo "    bench"
+	@echo "        Build and run benchmarks of the selected packages"
+	@echo "        that have a 'bench' directory. The output of each"
+	@echo "        package is appended to its 'make_bench.log'."
+	@echo
 	@echo "    install"
 	@echo "        Install package binaries and library headers into"
 	@echo "        '`+mtc.ws.installDir()+`'."
@@ -159,14 +185,21 @@ func (mtc *makefileTargetCollector) addConfigureTargets() {
 	}
 }
 
+// scriptTemplate returns a format string for the script that runs
+// 'projectTarget' in the build directory of a package. The optional
+// 'makeArgs' are passed to make after the target name.
 func (mtc *makefileTargetCollector) scriptTemplate(targetName,
-	projectTarget string) string {
+	projectTarget string, makeArgs ...string) string {
 	var logFileSuffix string
 	if projectTarget != "" {
 		logFileSuffix = "_" + projectTarget
 		projectTarget = " " + projectTarget
 	}
 
+	for _, arg := range makeArgs {
+		projectTarget += " " + strings.Replace(arg, "%", "%%", -1)
+	}
+
 	header := fmt.Sprintf(`	@echo '[%[1]s] %%[1]s'
 	@cd '`+mtc.relBuildDir+`/%%[1]s' && \
 	echo '--------------------------------' >> make%[2]s.log && \
@@ -225,6 +258,131 @@ func (mtc *makefileTargetCollector) addCheckTargets() {
 	}
 }
 
+func (mtc *makefileTargetCollector) addCoverageTargets() {
+	var selectedPkgNames []string
+	var mergeArgs string
+
+	for _, pd := range mtc.selection {
+		selectedPkgNames = append(selectedPkgNames,
+			"coverage_"+pd.PackageName)
+		mergeArgs += " \\\n			-a '" + path.Join(mtc.relBuildDir,
+			pd.PackageName, "coverage.info") + "'"
+	}
+
+	mtc.addTarget("coverage", true, selectedPkgNames,
+		`	@if command -v lcov > /dev/null; then \
+		lcov --quiet`+mergeArgs+` \
+			-o coverage.info && \
+		lcov --summary coverage.info; \
+	else \
+		echo "lcov is required to merge coverage reports" >&2; \
+		exit 1; \
+	fi
+`)
+
+	scriptTemplate := mtc.scriptTemplate("coverage", "coverage")
+
+	for _, pd := range mtc.selection {
+		dependencies := []string{mtc.makefileFor(pd)}
+
+		for _, dep := range mtc.selectedDeps[pd] {
+			dependencies = append(dependencies, dep.PackageName)
+		}
+
+		mtc.addTarget("coverage_"+pd.PackageName, true, dependencies,
+			fmt.Sprintf(scriptTemplate, pd.PackageName))
+	}
+}
+
+func (mtc *makefileTargetCollector) addDocsTargets() {
+	var libraries packageDefinitionList
+	isSelectedLibrary := map[*packageDefinition]bool{}
+
+	for _, pd := range mtc.selection {
+		if pd.isLibrary() {
+			libraries = append(libraries, pd)
+			isSelectedLibrary[pd] = true
+		}
+	}
+
+	var selectedPkgNames []string
+
+	for _, pd := range libraries {
+		selectedPkgNames = append(selectedPkgNames,
+			"docs_"+pd.PackageName)
+	}
+
+	mtc.addTarget("docs", true, selectedPkgNames, "")
+
+	for _, pd := range libraries {
+		dependencies := []string{mtc.makefileFor(pd)}
+
+		// Tag files of the dependencies are listed in
+		// topological order. Their pathnames are relative
+		// to the build directory of the package, whereas
+		// the HTML locations are relative to 'docs/html'.
+		var tagFiles []string
+
+		for _, dep := range pd.allRequired {
+			if isSelectedLibrary[dep] {
+				dependencies = append(dependencies,
+					"docs_"+dep.PackageName)
+				tagFiles = append(tagFiles, path.Join("..",
+					dep.PackageName, "docs",
+					dep.PackageName+".tag")+"="+
+					path.Join("../../..", dep.PackageName,
+						"docs/html"))
+			}
+		}
+
+		var scriptTemplate string
+		if len(tagFiles) == 0 {
+			scriptTemplate = mtc.scriptTemplate("docs", "docs")
+		} else {
+			scriptTemplate = mtc.scriptTemplate("docs", "docs",
+				"DOXYGEN_TAGFILES='"+
+					strings.Join(tagFiles, " ")+"'")
+		}
+
+		mtc.addTarget("docs_"+pd.PackageName, true, dependencies,
+			fmt.Sprintf(scriptTemplate, pd.PackageName))
+	}
+}
+
+func (mtc *makefileTargetCollector) addBenchTargets() {
+	var packagesWithBenchmarks packageDefinitionList
+
+	for _, pd := range mtc.selection {
+		if benchDir, _ := pd.params["bench_dir"].([]string); len(
+			benchDir) > 0 {
+			packagesWithBenchmarks = append(
+				packagesWithBenchmarks, pd)
+		}
+	}
+
+	var selectedPkgNames []string
+
+	for _, pd := range packagesWithBenchmarks {
+		selectedPkgNames = append(selectedPkgNames,
+			"bench_"+pd.PackageName)
+	}
+
+	mtc.addTarget("bench", true, selectedPkgNames, "")
+
+	scriptTemplate := mtc.scriptTemplate("bench", "bench")
+
+	for _, pd := range packagesWithBenchmarks {
+		dependencies := []string{mtc.makefileFor(pd)}
+
+		for _, dep := range mtc.selectedDeps[pd] {
+			dependencies = append(dependencies, dep.PackageName)
+		}
+
+		mtc.addTarget("bench_"+pd.PackageName, true, dependencies,
+			fmt.Sprintf(scriptTemplate, pd.PackageName))
+	}
+}
+
 func (mtc *makefileTargetCollector) addInstallTargets() {
 	var selectedPkgNames []string
 
@@ -0,0 +1,253 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// normalizeTestDriver validates the 'test_driver' parameter, which
+// selects the Automake test driver for the 'tests' directory.
+func normalizeTestDriver(pathname string, params templateParams) error {
+	switch value := params["test_driver"]; value {
+	case nil:
+		params["test_driver"] = "default"
+	case "default", "tap":
+	default:
+		return errors.New(pathname + ": 'test_driver' must be " +
+			"either 'default' or 'tap'")
+	}
+	return nil
+}
+
+// testResultKinds lists the test results that the Automake
+// parallel test harness reports, in the order of the columns
+// of the summary table.
+var testResultKinds = []string{"PASS", "FAIL", "SKIP", "XFAIL", "XPASS",
+	"ERROR"}
+
+// testResult is the outcome of a single test or, for the tests
+// that use the TAP driver, of a single TAP test point.
+type testResult struct {
+	name   string
+	result string
+	log    string
+}
+
+// testSuite contains the results of the tests that were run
+// in one directory of a package build tree.
+type testSuite struct {
+	name    string
+	counts  map[string]int
+	results []testResult
+}
+
+// parseTestSuiteLog reads the summary counters
+// ('# PASS:  3', etc.) from a test-suite.log file.
+func parseTestSuiteLog(contents string) map[string]int {
+	counts := map[string]int{}
+
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "# ") {
+			continue
+		}
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			continue
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(line[colon+1:]))
+		if err != nil {
+			continue
+		}
+		counts[strings.TrimSpace(line[2:colon])] = count
+	}
+
+	return counts
+}
+
+// parseTrsFile returns the test results recorded in a .trs file.
+// A .trs file produced by the default driver contains a single
+// result, whereas the TAP driver records a result for each test
+// point, followed by its number and description.
+func parseTrsFile(testName, contents string) []testResult {
+	var results []testResult
+
+	const resultPrefix = ":test-result:"
+
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, resultPrefix) {
+			continue
+		}
+		fields := strings.SplitN(strings.TrimSpace(
+			line[len(resultPrefix):]), " ", 2)
+		name := testName
+		if len(fields) > 1 {
+			name += " " + fields[1]
+		}
+		results = append(results, testResult{name, fields[0], ""})
+	}
+
+	return results
+}
+
+// readTestSuites finds the directories of the package build
+// tree where 'make check' has been run and reads the results
+// of the tests in those directories.
+func readTestSuites(pkgName, pkgBuildDir string) ([]*testSuite, error) {
+	var suites []*testSuite
+
+	err := filepath.Walk(pkgBuildDir, func(pathname string,
+		info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != "test-suite.log" {
+			return nil
+		}
+
+		contents, err := ioutil.ReadFile(pathname)
+		if err != nil {
+			return err
+		}
+
+		testDir := filepath.Dir(pathname)
+
+		relDir, err := filepath.Rel(pkgBuildDir, testDir)
+		if err != nil {
+			return err
+		}
+
+		suite := &testSuite{path.Join(pkgName, filepath.ToSlash(
+			relDir)), parseTestSuiteLog(string(contents)), nil}
+
+		trsFiles, err := filepath.Glob(filepath.Join(testDir, "*.trs"))
+		if err != nil {
+			return err
+		}
+		sort.Strings(trsFiles)
+
+		for _, trsFile := range trsFiles {
+			trs, err := ioutil.ReadFile(trsFile)
+			if err != nil {
+				return err
+			}
+
+			testName := strings.TrimSuffix(
+				filepath.Base(trsFile), ".trs")
+
+			results := parseTrsFile(testName, string(trs))
+
+			// Attach the test log to unsuccessful results.
+			for i := range results {
+				switch results[i].result {
+				case "FAIL", "XPASS", "ERROR":
+					testLog, err := ioutil.ReadFile(
+						strings.TrimSuffix(trsFile,
+							".trs") + ".log")
+					if err == nil {
+						results[i].log = string(testLog)
+					}
+				}
+			}
+
+			suite.results = append(suite.results, results...)
+		}
+
+		suites = append(suites, suite)
+
+		return nil
+	})
+
+	return suites, err
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+// junitReport converts the test results to the JUnit XML format.
+// Expected failures are reported as successful tests, and
+// unexpected passes are reported as failures.
+func junitReport(suites []*testSuite) ([]byte, error) {
+	var report junitTestSuites
+
+	for _, suite := range suites {
+		js := junitTestSuite{Name: suite.name}
+		className := strings.Replace(suite.name, "/", ".", -1)
+
+		for _, tr := range suite.results {
+			tc := junitTestCase{ClassName: className, Name: tr.name}
+
+			switch tr.result {
+			case "FAIL", "XPASS":
+				tc.Failure = &junitFailure{tr.result, tr.log}
+				js.Failures++
+			case "ERROR":
+				tc.Error = &junitFailure{tr.result, tr.log}
+				js.Errors++
+			case "SKIP":
+				tc.Skipped = &struct{}{}
+				js.Skipped++
+			}
+
+			js.TestCases = append(js.TestCases, tc)
+		}
+
+		js.Tests = len(js.TestCases)
+
+		report.Tests += js.Tests
+		report.Failures += js.Failures
+		report.Errors += js.Errors
+		report.Skipped += js.Skipped
+		report.TestSuites = append(report.TestSuites, js)
+	}
+
+	output, err := xml.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), append(output, '\n')...), nil
+}
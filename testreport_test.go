@@ -0,0 +1,75 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTestSuiteLog(t *testing.T) {
+	counts := parseTestSuiteLog(`==========================================
+   lib1 1.0: tests/test-suite.log
+==========================================
+
+# TOTAL: 3
+# PASS:  2
+# SKIP:  0
+# XFAIL: 0
+# FAIL:  1
+# XPASS: 0
+# ERROR: 0
+
+.. contents:: :depth: 2
+`)
+
+	if !reflect.DeepEqual(counts, map[string]int{"TOTAL": 3, "PASS": 2,
+		"SKIP": 0, "XFAIL": 0, "FAIL": 1, "XPASS": 0, "ERROR": 0}) {
+		t.Error("Unexpected test counts:", counts)
+	}
+}
+
+func TestParseTrsFile(t *testing.T) {
+	results := parseTrsFile("test_a", `:test-result: PASS
+:global-test-result: PASS
+:recheck: no
+:copy-in-global-log: no
+`)
+	if !reflect.DeepEqual(results,
+		[]testResult{{"test_a", "PASS", ""}}) {
+		t.Error("Unexpected result of a plain test:", results)
+	}
+
+	results = parseTrsFile("test_b", `:test-result: PASS 1 - first
+:test-result: SKIP 2 # SKIP not supported
+:global-test-result: PASS
+`)
+	if !reflect.DeepEqual(results, []testResult{
+		{"test_b 1 - first", "PASS", ""},
+		{"test_b 2 # SKIP not supported", "SKIP", ""}}) {
+		t.Error("Unexpected result of a TAP test:", results)
+	}
+}
+
+func TestJUnitReport(t *testing.T) {
+	report, err := junitReport([]*testSuite{{"lib1/tests", nil,
+		[]testResult{
+			{"test_a", "PASS", ""},
+			{"test_b", "FAIL", "assertion failed"},
+			{"test_c", "XFAIL", ""}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		`<testsuites tests="3" failures="1" errors="0" skipped="0">`,
+		`<testcase classname="lib1.tests" name="test_a"></testcase>`,
+		`<failure message="FAIL">assertion failed</failure>`} {
+		if !strings.Contains(string(report), expected) {
+			t.Error("Report does not contain " + expected)
+		}
+	}
+}
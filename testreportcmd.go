@@ -0,0 +1,142 @@
+// Copyright (C) 2017, 2018 Damon Revoe. All rights reserved.
+// Use of this source code is governed by the MIT
+// license, which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var testReportFilename = "test-report.xml"
+
+func printTestSummary(selection packageDefinitionList,
+	suitesByPkg map[*packageDefinition][]*testSuite) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(w, "PACKAGE\tTOTAL\t"+
+		strings.Join(testResultKinds, "\t"))
+
+	for _, pd := range selection {
+		suites := suitesByPkg[pd]
+		if len(suites) == 0 {
+			// The tests of this package have not been run.
+			fmt.Fprintln(w, pd.PackageName+strings.Repeat("\t-",
+				len(testResultKinds)+1))
+			continue
+		}
+
+		total := 0
+		counts := map[string]int{}
+		for _, suite := range suites {
+			total += suite.counts["TOTAL"]
+			for _, kind := range testResultKinds {
+				counts[kind] += suite.counts[kind]
+			}
+		}
+
+		row := pd.PackageName + "\t" + strconv.Itoa(total)
+		for _, kind := range testResultKinds {
+			row += "\t" + strconv.Itoa(counts[kind])
+		}
+		fmt.Fprintln(w, row)
+	}
+
+	w.Flush()
+}
+
+func generateTestReport(args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+
+	pi, err := readPackageDefinitions(ws.wp)
+	if err != nil {
+		return err
+	}
+
+	var selection packageDefinitionList
+
+	if len(args) > 0 {
+		selection, err = packageRangesToFlatSelection(pi, args)
+	} else {
+		selection, err = readPackageSelection(pi, ws.absPrivateDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	var allSuites []*testSuite
+	suitesByPkg := map[*packageDefinition][]*testSuite{}
+
+	for _, pd := range selection {
+		pkgBuildDir := path.Join(ws.buildDir(), pd.PackageName)
+
+		if _, err := os.Stat(pkgBuildDir); os.IsNotExist(err) {
+			continue
+		}
+
+		suites, err := readTestSuites(pd.PackageName, pkgBuildDir)
+		if err != nil {
+			return err
+		}
+
+		suitesByPkg[pd] = suites
+		allSuites = append(allSuites, suites...)
+	}
+
+	report, err := junitReport(allSuites)
+	if err != nil {
+		return err
+	}
+
+	reportPathname := flags.testReport
+	if reportPathname == "" {
+		reportPathname = path.Join(ws.absDir, testReportFilename)
+	}
+
+	if err = ioutil.WriteFile(reportPathname, report, 0644); err != nil {
+		return err
+	}
+
+	if !flags.quiet {
+		printTestSummary(selection, suitesByPkg)
+	}
+
+	return nil
+}
+
+// testReportCmd represents the test-report command
+var testReportCmd = &cobra.Command{
+	Use:   "test-report [package_range...]",
+	Short: "Collect the results of 'make check' into a JUnit XML file",
+	Long: wrapText("Collect the results of the most recent " +
+		"'make check' run of the selected packages (or the " +
+		"specified package range) from their build directories, " +
+		"write them to a JUnit XML file, and display a summary " +
+		"table."),
+	Run: func(_ *cobra.Command, args []string) {
+		if err := generateTestReport(args); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(testReportCmd)
+
+	testReportCmd.Flags().SortFlags = false
+	addQuietFlag(testReportCmd)
+	addTestReportFlag(testReportCmd)
+	addWorkspaceDirFlag(testReportCmd)
+}
@@ -7,13 +7,447 @@ package main
 var commonDefinitions = map[string]string{
 	"FileHeader": `{{if .header}}{{Comment .header}}
 {{end}}`,
-	"Snippet": `{{if .snippets}}{{if index .snippets .filename}}
-{{index .snippets .filename}}{{end}}{{end}}`,
+	"Snippet": `{{template "SnippetChecks" .}}{{template "SnippetBottom" .}}`,
+	"SnippetTop": `{{with index .snippets (print .filename ":top")}}{{.}}
+{{end}}`,
+	"SnippetBottom": `{{with index .snippets (print .filename ":bottom")}}
+{{.}}{{end}}`,
+	"SnippetChecks": `{{with index .snippets (print .filename ":checks")}}
+{{.}}{{end}}`,
+	"SnippetOutput": `{{with index .snippets (print .filename ":output")}}
+{{.}}
+{{end}}`,
 	"Multiline": `{{range .}} \
 	{{.}}{{end}}`,
+	"CXXFlags": `{{if .cxx_std -}}
+AX_CXX_COMPILE_STDCXX([{{.cxx_std}}], [noext], [mandatory])
+
+{{end -}}
+{{if .warning_flags -}}
+dnl When compiling with GNU C++ or Clang, display more warnings.
+AS_IF([test "$GXX" = yes],
+	[CXXFLAGS="$CXXFLAGS {{.warning_flags}}"])
+
+{{end -}}
+AC_ARG_ENABLE(debug, AS_HELP_STRING([--enable-debug],
+	[enable debug info and runtime checks (default=no)]))
+
+AM_CONDITIONAL(DEBUG, [test "$enable_debug" = yes])
+
+AS_IF([test "$enable_debug" != yes],
+	[CXXFLAGS="$CXXFLAGS -O3"],
+[CPPFLAGS="$CPPFLAGS -D{{VarNameUC .name}}_DEBUG"
+AS_IF([test "$GXX" = yes],
+	[CXXFLAGS="$CXXFLAGS -ggdb"],
+[test "$ac_cv_prog_cxx_g" = yes],
+	[CXXFLAGS="$CXXFLAGS -g"])])
+
+AC_ARG_ENABLE(asan, AS_HELP_STRING([--enable-asan],
+	[instrument the code with AddressSanitizer (default=no)]))
+
+AC_ARG_ENABLE(ubsan, AS_HELP_STRING([--enable-ubsan],
+	[instrument the code with UndefinedBehaviorSanitizer (default=no)]))
+
+AC_ARG_ENABLE(tsan, AS_HELP_STRING([--enable-tsan],
+	[instrument the code with ThreadSanitizer (default=no)]))
+
+AC_ARG_ENABLE(coverage, AS_HELP_STRING([--enable-coverage],
+	[collect test coverage data using gcov (default=no)]))
+
+AS_IF([test "$enable_asan" = yes && test "$enable_tsan" = yes],
+	[AC_MSG_ERROR([--enable-asan and --enable-tsan are mutually exclusive])])
+
+AS_IF([test "$enable_asan" = yes],
+	[CXXFLAGS="$CXXFLAGS -fsanitize=address -fno-omit-frame-pointer"
+	LDFLAGS="$LDFLAGS -fsanitize=address"])
+
+AS_IF([test "$enable_ubsan" = yes],
+	[CXXFLAGS="$CXXFLAGS -fsanitize=undefined -fno-omit-frame-pointer"
+	LDFLAGS="$LDFLAGS -fsanitize=undefined"])
+
+AS_IF([test "$enable_tsan" = yes],
+	[CXXFLAGS="$CXXFLAGS -fsanitize=thread"
+	LDFLAGS="$LDFLAGS -fsanitize=thread"])
+
+AM_CONDITIONAL(COVERAGE, [test "$enable_coverage" = yes])
+
+AS_IF([test "$enable_coverage" = yes],
+	[CXXFLAGS="$CXXFLAGS -O0 --coverage"
+	LDFLAGS="$LDFLAGS --coverage"
+	AC_CHECK_PROGS([LCOV], [lcov])
+	AC_CHECK_PROGS([GCOV], [gcov])])
+`,
+	"VersionHeader": `{{$prefix := VarNameUC .name -}}
+{{$version := SplitVersion .version -}}
+/* Version information for {{.name}}. */
+
+#ifndef {{$prefix}}_VERSION_H
+#define {{$prefix}}_VERSION_H
+
+#define {{$prefix}}_VERSION "{{.version}}"
+#define {{$prefix}}_VERSION_MAJOR {{index $version 0}}
+#define {{$prefix}}_VERSION_MINOR {{index $version 1}}
+#define {{$prefix}}_VERSION_PATCH {{index $version 2}}
+{{if .version_info}}{{$versionInfo := SplitVersion .version_info}}
+#define {{$prefix}}_VERSION_INFO "{{.version_info}}"
+#define {{$prefix}}_LT_CURRENT {{index $versionInfo 0}}
+#define {{$prefix}}_LT_REVISION {{index $versionInfo 1}}
+#define {{$prefix}}_LT_AGE {{index $versionInfo 2}}
+{{end}}{{with Requires}}
+/* Versions of the required packages. */
+{{range .}}#define {{$prefix}}_REQUIRES_{{VarNameUC .PackageName -}}
+_VERSION "{{.Params.version}}"
+{{end}}{{end}}
+#endif /* !defined({{$prefix}}_VERSION_H) */
+`,
+	"ExportHeader": `{{$guard := print (VarNameUC .name) "_EXPORT_H" -}}
+/* {{.name}} symbol visibility macros. */
+
+#ifndef {{$guard}}
+#define {{$guard}}
+
+{{$api := print (VarNameUC .name) "_API" -}}
+{{$local := print (VarNameUC .name) "_LOCAL" -}}
+{{$building := print (VarNameUC .name) "_BUILDING" -}}
+#if defined(_WIN32) || defined(__CYGWIN__)
+#ifdef DLL_EXPORT
+#ifdef {{$building}}
+#define {{$api}} __declspec(dllexport)
+#else
+#define {{$api}} __declspec(dllimport)
+#endif
+#else
+#define {{$api}}
+#endif
+#define {{$local}}
+#elif defined(__GNUC__) && __GNUC__ >= 4
+#define {{$api}} __attribute__((visibility("default")))
+#define {{$local}} __attribute__((visibility("hidden")))
+#else
+#define {{$api}}
+#define {{$local}}
+#endif
+
+#endif /* !defined({{$guard}}) */
+`,
+	"ConfigureOptions": `{{range .options}}
+{{if eq .Kind "enable"}}AC_ARG_ENABLE{{else}}AC_ARG_WITH{{end -}}
+([{{.Name}}], AS_HELP_STRING([{{.HelpFlag}}],
+	[{{.Help}} (default={{.DefaultValue}})]),
+	[], [{{.ShellVar}}={{.DefaultValue}}])
+{{if .Define}}
+AS_IF([test "${{.ShellVar}}" != no],
+	[AC_DEFINE([{{.Define}}], [1], [{{.Help}}])])
+{{end}}
+AM_CONDITIONAL([{{.Conditional}}], [test "${{.ShellVar}}" != no])
+{{end}}`,
+	"GrammarTools": `{{if Select (Dir "src") (StringList "*?.l" "*?.ll" "*?.y" "*?.yy") -}}
+AC_PROG_CC
+AM_PROG_LEX
+AC_PROG_YACC
+{{end}}`,
+	"GeneratedSources": `{{with .}}
+BUILT_SOURCES ={{range .}}{{template "Multiline" .Outputs}}{{end}}
+
+CLEANFILES = $(BUILT_SOURCES)
+{{range .}}
+{{.FirstOutput}}:{{range .Inputs}} {{.}}{{end}}
+	{{.Command}}
+{{$firstOutput := .FirstOutput -}}
+{{range .OtherOutputs}}
+{{.}}: {{$firstOutput}}
+{{end}}{{end}}{{end}}`,
+	"ManMakefile": `{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+{{$allFiles := Dir .dirname -}}
+{{$pageExt := StringList "*?.[1-9]" -}}
+man_MANS ={{template "Multiline" Select $allFiles $pageExt}}
+
+EXTRA_DIST = $(man_MANS){{template "Multiline" Exclude $allFiles $pageExt}}
+{{template "SnippetBottom" .}}`,
+	"DataMakefile": `{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+{{$allFiles := Dir .dirname -}}
+{{$nested := StringList "*/**/*" -}}
+{{$topLevel := Exclude $allFiles $nested -}}
+{{with $topLevel -}}
+dist_pkgdata_DATA ={{template "Multiline" .}}
+{{end -}}
+{{with Select $allFiles $nested -}}
+{{if $topLevel}}
+{{end -}}
+nobase_dist_pkgdata_DATA ={{template "Multiline" .}}
+{{end -}}
+{{template "SnippetBottom" .}}`,
+	"DocMakefile": `{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+{{$allFiles := Dir .dirname -}}
+{{$nested := StringList "*/**/*" -}}
+{{$topLevel := Exclude $allFiles $nested -}}
+{{with $topLevel -}}
+dist_doc_DATA ={{template "Multiline" .}}
+{{end -}}
+{{with Select $allFiles $nested -}}
+{{if $topLevel}}
+{{end -}}
+nobase_dist_doc_DATA ={{template "Multiline" .}}
+{{end -}}
+{{template "SnippetBottom" .}}`,
+	"ProgramBuildFlags": `{{if or (eq .type "lib") (eq .type "library") -}}
+LDADD = ../src/lib$(PACKAGE).la
+{{- else -}}
+AM_CPPFLAGS = -I$(top_srcdir)/src -I$(top_builddir)/src
+{{- end}}`,
+	"ExamplesMakefile": `{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" -}}
+{{$allFiles := Dir .dirname -}}
+{{$exampleSources := Select $allFiles $sourceExt -}}
+{{if $exampleSources -}}
+{{template "ProgramBuildFlags" .}}
+
+noinst_PROGRAMS ={{range $exampleSources}} \
+	{{TrimExt .}}{{end}}
+{{range $exampleSources}}
+{{VarName (TrimExt .)}}_SOURCES = {{.}}
+{{end}}{{end -}}
+{{$extraFiles := Exclude $allFiles $sourceExt -}}
+{{if $extraFiles}}
+EXTRA_DIST ={{template "Multiline" $extraFiles}}
+{{end -}}
+{{template "SnippetBottom" .}}`,
+	"BenchMakefile": `{{template "FileHeader" . -}}
+{{template "SnippetTop" . -}}
+{{$sourceExt := StringList "*?.C" "*?.c" "*?.cc" "*?.cxx" "*?.cpp" -}}
+{{$allFiles := Dir .dirname -}}
+{{$benchSources := Select $allFiles $sourceExt -}}
+{{if eq (len $benchSources) 0}}
+{{Error "no benchmark sources found in bench/"}}
+{{end -}}
+{{template "ProgramBuildFlags" .}}
+
+noinst_PROGRAMS ={{range $benchSources}} \
+	{{TrimExt .}}{{end}}
+{{range $benchSources}}
+{{VarName (TrimExt .)}}_SOURCES = {{.}}
+{{end}}
+# Run all benchmarks one after another.
+bench: $(noinst_PROGRAMS)
+	@for prog in $(noinst_PROGRAMS); do \
+		echo "$$prog:"; \
+		./$$prog || exit 1; \
+	done
+
+.PHONY: bench
+{{$extraFiles := Exclude $allFiles $sourceExt -}}
+{{if $extraFiles}}
+EXTRA_DIST ={{template "Multiline" $extraFiles}}
+{{end -}}
+{{template "SnippetBottom" .}}`,
+	"HeaderTestSource": `header_{{VarName .}}.cc`,
+	"HeaderTestRule": `{{template "HeaderTestSource" .}}:
+	echo '#include <{{.}}>' > $@
+`,
+	"PkgConfigBody": `Name: @PACKAGE_NAME@
+Description: {{.description}}
+Version: @PACKAGE_VERSION@
+{{with .public_requires}}Requires:{{range .}} {{.}}{{end}}
+{{end -}}
+{{with .private_requires}}Requires.private:{{range .}} {{.}}{{end}}
+{{end -}}
+`,
+	"PkgConfigLibsPrivate": `{{with .external_libs}}Libs.private:{{range .}} -l{{.name}}
+{{- with .other_libs}} {{.}}{{end}}{{end}}
+{{end -}}
+`,
+	"CoverageTarget": `if COVERAGE
+coverage: check
+	@if test -n "$(LCOV)"; then \
+		$(LCOV) --quiet --capture --directory . \
+			--output-file coverage.info && \
+		$(LCOV) --quiet --remove coverage.info '/usr/*' \
+			--output-file coverage.info && \
+		$(LCOV) --summary coverage.info; \
+	elif test -n "$(GCOV)"; then \
+		find . -name '*.gcda' -exec $(GCOV) -p {} +; \
+	else \
+		echo "Neither lcov nor gcov is available" >&2; exit 1; \
+	fi
+else
+coverage:
+	@echo "Coverage data is not collected;" \
+		"reconfigure with --enable-coverage" >&2; exit 1
+endif
+
+clean-coverage:
+	-rm -f coverage.info *.gcov
+
+.PHONY: coverage clean-coverage
+`,
+}
+
+// withCommonDefinitions returns a new map that contains
+// the common definitions and the specified definitions.
+func withCommonDefinitions(definitions map[string]string) map[string]string {
+	result := make(map[string]string,
+		len(commonDefinitions)+len(definitions))
+
+	for name, text := range commonDefinitions {
+		result[name] = text
+	}
+	for name, text := range definitions {
+		result[name] = text
+	}
+
+	return result
 }
 
 var commonTemplateFiles = []embeddedTemplateFile{
+	{"m4/ax_cxx_compile_stdcxx.m4", 0644,
+		[]byte(`# ============================================================
+#  AX_CXX_COMPILE_STDCXX(VERSION, [ext|noext], [mandatory|optional])
+# ============================================================
+#
+# Check for baseline language coverage in the compiler for the
+# specified version of the C++ standard. If necessary, add a
+# switch to CXX to enable support. VERSION may be '11', '14',
+# '17', or '20'.
+#
+# The second argument, if specified, indicates whether to insist
+# on an extended mode (e.g. -std=gnu++11) or a strict conformance
+# mode (e.g. -std=c++11). If neither is specified, the default is
+# the strict mode if available.
+#
+# The third argument, if specified 'mandatory' or if left
+# unspecified, indicates that baseline support for the specified
+# C++ standard is required and that the macro should error out
+# if no mode with that support is found. If specified 'optional',
+# then configuration proceeds regardless, after defining
+# HAVE_CXX${VERSION} if and only if a supporting mode is found.
+#
+# This is a reduced version of the macro from the GNU Autoconf
+# Archive that only checks the value of __cplusplus:
+#
+#   https://www.gnu.org/software/autoconf-archive/ax_cxx_compile_stdcxx.html
+#
+# LICENSE
+#
+#   Copyright (c) 2008 Benjamin Kosnik <bkoz@redhat.com>
+#   Copyright (c) 2012 Zack Weinberg <zackw@panix.com>
+#   Copyright (c) 2013 Roy Stogner <roystgnr@ices.utexas.edu>
+#   Copyright (c) 2014, 2015 Google Inc.; contributed by Alexey Sokolov <sokolov@google.com>
+#   Copyright (c) 2015 Paul Norman <penorman@mac.com>
+#   Copyright (c) 2015 Moritz Klammler <moritz@klammler.eu>
+#   Copyright (c) 2016, 2018 Krzesimir Nowak <qdlacz@gmail.com>
+#   Copyright (c) 2019 Enji Cooper <yaneurabeya@gmail.com>
+#   Copyright (c) 2020 Jason Merrill <jason@redhat.com>
+#   Copyright (c) 2021 Jörn Heusipp <osmanx@problemloesungsmaschine.de>
+#
+#   Copying and distribution of this file, with or without modification, are
+#   permitted in any medium without royalty provided the copyright notice
+#   and this notice are preserved.  This file is offered as-is, without any
+#   warranty.
+
+#serial 1
+
+AC_DEFUN([AX_CXX_COMPILE_STDCXX], [dnl
+  m4_if([$1], [11], [ax_cxx_compile_alternatives="11 0x"
+    ax_cxx_compile_cplusplus=201103L],
+    [$1], [14], [ax_cxx_compile_alternatives="14 1y"
+    ax_cxx_compile_cplusplus=201402L],
+    [$1], [17], [ax_cxx_compile_alternatives="17 1z"
+    ax_cxx_compile_cplusplus=201703L],
+    [$1], [20], [ax_cxx_compile_alternatives="20 2a"
+    ax_cxx_compile_cplusplus=202002L],
+    [m4_fatal([invalid first argument to AX_CXX_COMPILE_STDCXX])])dnl
+  m4_if([$2], [], [],
+    [$2], [ext], [],
+    [$2], [noext], [],
+    [m4_fatal([invalid second argument to AX_CXX_COMPILE_STDCXX])])dnl
+  m4_if([$3], [], [ax_cxx_compile_cxx$1_required=true],
+    [$3], [mandatory], [ax_cxx_compile_cxx$1_required=true],
+    [$3], [optional], [ax_cxx_compile_cxx$1_required=false],
+    [m4_fatal([invalid third argument to AX_CXX_COMPILE_STDCXX])])
+  AC_LANG_PUSH([C++])dnl
+  ac_success=no
+
+  m4_if([$2], [], [dnl
+    AC_CACHE_CHECK([whether $CXX supports C++$1 features by default],
+      [ax_cv_cxx_compile_cxx$1],
+      [AC_COMPILE_IFELSE([_AX_CXX_COMPILE_STDCXX_test_body],
+        [ax_cv_cxx_compile_cxx$1=yes],
+        [ax_cv_cxx_compile_cxx$1=no])])
+    if test x$ax_cv_cxx_compile_cxx$1 = xyes; then
+      ac_success=yes
+    fi])
+
+  m4_if([$2], [noext], [], [dnl
+  if test x$ac_success = xno; then
+    for alternative in ${ax_cxx_compile_alternatives}; do
+      switch="-std=gnu++${alternative}"
+      cachevar=AS_TR_SH([ax_cv_cxx_compile_cxx$1_$switch])
+      AC_CACHE_CHECK([whether $CXX supports C++$1 features with $switch],
+        $cachevar,
+        [ac_save_CXX="$CXX"
+         CXX="$CXX $switch"
+         AC_COMPILE_IFELSE([_AX_CXX_COMPILE_STDCXX_test_body],
+          [eval $cachevar=yes],
+          [eval $cachevar=no])
+         CXX="$ac_save_CXX"])
+      if eval test x\$$cachevar = xyes; then
+        CXX="$CXX $switch"
+        ac_success=yes
+        break
+      fi
+    done
+  fi])
+
+  m4_if([$2], [ext], [], [dnl
+  if test x$ac_success = xno; then
+    for alternative in ${ax_cxx_compile_alternatives}; do
+      switch="-std=c++${alternative}"
+      cachevar=AS_TR_SH([ax_cv_cxx_compile_cxx$1_$switch])
+      AC_CACHE_CHECK([whether $CXX supports C++$1 features with $switch],
+        $cachevar,
+        [ac_save_CXX="$CXX"
+         CXX="$CXX $switch"
+         AC_COMPILE_IFELSE([_AX_CXX_COMPILE_STDCXX_test_body],
+          [eval $cachevar=yes],
+          [eval $cachevar=no])
+         CXX="$ac_save_CXX"])
+      if eval test x\$$cachevar = xyes; then
+        CXX="$CXX $switch"
+        ac_success=yes
+        break
+      fi
+    done
+  fi])
+  AC_LANG_POP([C++])
+
+  if test x$ax_cxx_compile_cxx$1_required = xtrue; then
+    if test x$ac_success = xno; then
+      AC_MSG_ERROR([*** A compiler with support for C++$1 language features is required.])
+    fi
+  fi
+  if test x$ac_success = xno; then
+    HAVE_CXX$1=0
+    AC_MSG_NOTICE([No compiler with C++$1 support was found])
+  else
+    HAVE_CXX$1=1
+    AC_DEFINE(HAVE_CXX$1,1,
+              [define if the compiler supports basic C++$1 syntax])
+  fi
+  AC_SUBST(HAVE_CXX$1)
+])
+
+m4_define([_AX_CXX_COMPILE_STDCXX_test_body], [AC_LANG_SOURCE([[
+#ifndef __cplusplus
+#error "This is not a C++ compiler"
+#elif __cplusplus < $ax_cxx_compile_cplusplus
+#error "The requested C++ standard is not supported"
+#endif
+]])])
+`)},
 	{"autogen.sh", 0755,
 		[]byte(`#!/bin/sh
 
@@ -19,6 +19,8 @@ type workspaceParams struct {
 	DefaultMakeTarget string `yaml:"default-target,omitempty"`
 	BuildDir          string `yaml:"builddir,omitempty"`
 	InstallDir        string `yaml:"installdir,omitempty"`
+	Backend           string `yaml:"backend,omitempty"`
+	Generator         string `yaml:"generator,omitempty"`
 }
 
 type workspace struct {
@@ -104,3 +106,9 @@ func (ws *workspace) pkgRootDirRelativeToWorkspace() string {
 func (ws *workspace) buildDirRelativeToWorkspace() string {
 	return ws.relativeToWorkspace(ws.buildDir())
 }
+
+// backend returns the build backend that
+// was chosen when the workspace was initialized.
+func (ws *workspace) backend() (buildBackend, error) {
+	return getBackend(ws.wp.Backend)
+}
@@ -31,8 +31,38 @@ all: build
 {{end}}`)},
 }
 
-func generateWorkspaceFiles(ws *workspace, pi *packageIndex,
-	selection packageDefinitionList, conftab *Conftab) error {
+const ninjaFilename = "build.ninja"
+
+var ninjaTemplate = embeddedTemplateFile{ninjaFilename, 0644,
+	[]byte(`ninja_required_version = 1.5
+
+pool configure
+  depth = 1
+
+rule run
+  command = $cmd
+  description = $out
+
+rule refresh
+  command = $cmd
+  description = Regenerating $out
+  generator = 1
+  restat = 1
+
+build FORCE: phony
+
+{{range .ninja_edges}}build {{.Output}}: {{.Rule}}{{range .Inputs}} {{.}}{{end}}
+{{with .Command}}  cmd = {{.}}
+{{end}}{{with .Pool}}  pool = {{.}}
+{{end}}
+{{end}}build all: phony build
+
+default {{.default_target}}
+`)}
+
+func generateWorkspaceFiles(ws *workspace, backend buildBackend,
+	pi *packageIndex, selection packageDefinitionList,
+	conftab *Conftab) error {
 
 	makefile := ws.wp.Makefile
 	if flags.makefile != "" {
@@ -48,15 +78,26 @@ func generateWorkspaceFiles(ws *workspace, pi *packageIndex,
 		defaultTarget = "help"
 	}
 
+	targets := backend.makefileTargets(ws, selection, pi)
+
 	params := templateParams{
 		"makefile":       makefile,
 		"default_target": defaultTarget,
 		"selection":      selection,
 		"conftab":        conftab,
-		"targets":        createMakefileTargets(ws, selection, pi),
+		"targets":        targets,
+	}
+
+	templateFiles := workspaceTemplate
+
+	if ws.wp.Generator == ninjaGenerator {
+		params["ninja_edges"] = append([]ninjaEdge{
+			ninjaGeneratorEdge(ws)}, ninjaEdges(targets)...)
+		templateFiles = append(append([]embeddedTemplateFile{},
+			workspaceTemplate...), ninjaTemplate)
 	}
 
-	for _, templateFile := range workspaceTemplate {
+	for _, templateFile := range templateFiles {
 		fileParams := expandPathnameTemplate(templateFile.pathname,
 			params)
 